@@ -10,6 +10,6 @@ import (
 func main() {
 	if err := awsctx.Run(os.Args); err != nil {
 		fmt.Fprintf(os.Stderr, "error: %s\n", err)
-		os.Exit(1)
+		os.Exit(awsctx.ExitCode(err))
 	}
 }
@@ -0,0 +1,130 @@
+package awsctx
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// configDir returns the directory for user-provided awsctx settings.
+func configDir() string {
+	if d := os.Getenv("XDG_CONFIG_HOME"); d != "" {
+		return filepath.Join(d, "awsctx")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "awsctx")
+}
+
+// loadAccountNames reads the optional accounts.json mapping of account ID to
+// a human-readable name, e.g. {"123456789012": "Production"}.
+func loadAccountNames() map[string]string {
+	names := make(map[string]string)
+	data, err := os.ReadFile(filepath.Join(configDir(), "accounts.json"))
+	if err != nil {
+		return names
+	}
+	if err := json.Unmarshal(data, &names); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: ignoring %s: %v\n", filepath.Join(configDir(), "accounts.json"), err)
+	}
+	return names
+}
+
+// isAccountID reports whether s looks like a 12-digit AWS account ID.
+func isAccountID(s string) bool {
+	if len(s) != 12 {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// accountFromKeys derives the account ID from a profile's settings:
+// sso_account_id, or the account segment of role_arn.
+func accountFromKeys(keys map[string]string) string {
+	if id := keys["sso_account_id"]; id != "" {
+		return id
+	}
+	// arn:aws:iam::123456789012:role/name
+	if parts := strings.Split(keys["role_arn"], ":"); len(parts) >= 5 && isAccountID(parts[4]) {
+		return parts[4]
+	}
+	return ""
+}
+
+// getProfileAccounts maps each profile to its account ID, from the config
+// file or, failing that, from a cached whoami identity. No network calls.
+func getProfileAccounts(profiles []string) map[string]string {
+	accounts := make(map[string]string)
+	cfg, err := loadConfig()
+	if err != nil {
+		return accounts
+	}
+	for _, p := range profiles {
+		if id := accountFromKeys(cfg.profileKeys(p)); id != "" {
+			accounts[p] = id
+		} else if cached := readIdentity(p); cached != nil {
+			accounts[p] = cached.Account
+		}
+	}
+	return accounts
+}
+
+// profileLabels returns one display line per profile: the name, followed by
+// account ID and account name columns when any are known, and markers for
+// profiles defined only in the credentials file and pinned profiles.
+func profileLabels(entries []profileEntry) []string {
+	profiles := make([]string, len(entries))
+	width := 0
+	for i, e := range entries {
+		profiles[i] = e.Name
+		width = max(width, len(e.Name))
+	}
+	accounts := getProfileAccounts(profiles)
+	names := loadAccountNames()
+	pins := pinnedSet("profile")
+
+	labels := make([]string, len(entries))
+	for i, e := range entries {
+		var cols []string
+		if id := accounts[e.Name]; id != "" {
+			cols = append(cols, id)
+			if names[id] != "" {
+				cols = append(cols, names[id])
+			}
+		}
+		if !e.InConfig {
+			cols = append(cols, "(credentials)")
+		}
+		if pins[e.Name] {
+			cols = append(cols, pinnedMarker)
+		}
+		if len(cols) == 0 {
+			labels[i] = e.Name
+			continue
+		}
+		labels[i] = fmt.Sprintf("%-*s  %s", width, e.Name, strings.Join(cols, "  "))
+	}
+	return labels
+}
+
+// profilesForAccount returns the profiles whose account ID is id.
+func profilesForAccount(id string) []string {
+	profiles, err := getProfiles()
+	if err != nil {
+		return nil
+	}
+	accounts := getProfileAccounts(profiles)
+	var matches []string
+	for _, p := range profiles {
+		if accounts[p] == id {
+			matches = append(matches, p)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,98 @@
+package awsctx
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testAccountsConfig = `[default]
+region = eu-west-1
+
+[profile prod]
+sso_account_id = 111111111111
+sso_role_name = Admin
+
+[profile prod-ro]
+role_arn = arn:aws:iam::111111111111:role/ReadOnly
+source_profile = default
+
+[profile sandbox]
+role_arn = arn:aws:iam::222222222222:role/Admin
+
+[profile local]
+region = us-east-1
+`
+
+func TestAccountFromKeys(t *testing.T) {
+	tests := []struct {
+		keys map[string]string
+		want string
+	}{
+		{map[string]string{"sso_account_id": "111111111111"}, "111111111111"},
+		{map[string]string{"role_arn": "arn:aws:iam::222222222222:role/x"}, "222222222222"},
+		{map[string]string{"role_arn": "not-an-arn"}, ""},
+		{map[string]string{"region": "us-east-1"}, ""},
+	}
+	for _, tt := range tests {
+		if got := accountFromKeys(tt.keys); got != tt.want {
+			t.Errorf("accountFromKeys(%v) = %q, want %q", tt.keys, got, tt.want)
+		}
+	}
+}
+
+func TestIsAccountID(t *testing.T) {
+	if !isAccountID("123456789012") {
+		t.Error("123456789012 should be an account ID")
+	}
+	for _, s := range []string{"", "12345678901", "12345678901a", "dev"} {
+		if isAccountID(s) {
+			t.Errorf("%q should not be an account ID", s)
+		}
+	}
+}
+
+func TestProfileLabels(t *testing.T) {
+	cleanup := setupTestAWS(t, testAccountsConfig, "")
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	os.MkdirAll(configDir(), 0o755)
+	os.WriteFile(filepath.Join(configDir(), "accounts.json"), []byte(`{"111111111111": "Production"}`), 0o644)
+
+	entries, _ := getProfileEntries()
+	want := []string{
+		"default",
+		"prod     111111111111  Production",
+		"prod-ro  111111111111  Production",
+		"sandbox  222222222222",
+		"local",
+	}
+	if got := profileLabels(entries); !reflect.DeepEqual(got, want) {
+		t.Errorf("profileLabels:\ngot  %q\nwant %q", got, want)
+	}
+}
+
+func TestProfileLabels_NoAccounts(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	entries, _ := getProfileEntries()
+	profiles, _ := getProfiles()
+	if got := profileLabels(entries); !reflect.DeepEqual(got, profiles) {
+		t.Errorf("expected plain names, got %q", got)
+	}
+}
+
+func TestProfilesForAccount(t *testing.T) {
+	cleanup := setupTestAWS(t, testAccountsConfig, "")
+	defer cleanup()
+
+	if got := profilesForAccount("222222222222"); !reflect.DeepEqual(got, []string{"sandbox"}) {
+		t.Errorf("expected [sandbox], got %v", got)
+	}
+	if got := profilesForAccount("111111111111"); !reflect.DeepEqual(got, []string{"prod", "prod-ro"}) {
+		t.Errorf("expected [prod prod-ro], got %v", got)
+	}
+}
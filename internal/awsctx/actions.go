@@ -0,0 +1,183 @@
+package awsctx
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// pickAction is a picker key that acts on the highlighted entry instead of
+// switching to it.
+type pickAction struct {
+	Key  string
+	Help string
+}
+
+var pickActions = []pickAction{
+	{"ctrl-e", "shell"},
+	{"ctrl-y", "copy exports"},
+	{"ctrl-d", "describe"},
+	{"ctrl-r", "pick region"},
+}
+
+// actionKeys returns the action keys offered when picking kind. Only a
+// profile can be followed by picking its region.
+func actionKeys(kind string) []string {
+	var keys []string
+	for _, a := range pickActions {
+		if a.Key != "ctrl-r" || kind == "profile" {
+			keys = append(keys, a.Key)
+		}
+	}
+	return keys
+}
+
+// actionHeader is the key help shown above the fzf list.
+func actionHeader(keys []string) string {
+	parts := []string{"enter: switch"}
+	for _, a := range pickActions {
+		if contains(keys, a.Key) {
+			parts = append(parts, a.Key+": "+a.Help)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// choose runs the picker for kind, prefiltered by query, and switches to
+// the choice with set, or runs the action of the key it was chosen with.
+func choose(kind, query string, set func(choice string) error) error {
+	key, choice, err := runPicker(kind, actionKeys(kind), query)
+	if err != nil {
+		return err
+	}
+	if choice == "" {
+		if kind == "pick" {
+			return cancelledErrorf("no profile and region selected")
+		}
+		return cancelledErrorf("no %s selected", kind)
+	}
+
+	switch key {
+	case "":
+		return set(choice)
+	case "ctrl-e":
+		return execShell(actionEnv(kind, choice))
+	case "ctrl-y":
+		return copyExports(actionEnv(kind, choice))
+	case "ctrl-d":
+		return describe(kind, choice)
+	case "ctrl-r":
+		if err := set(choice); err != nil {
+			return err
+		}
+		return choose("region", "", setRegion)
+	default:
+		return fmt.Errorf("unexpected picker key: %s", key)
+	}
+}
+
+// actionEnv returns the environment variables that select a profile, a
+// region or a "profile region" pair (kind) for a single process.
+func actionEnv(kind, choice string) []string {
+	var env []string
+	profile, region := choice, choice
+	if kind == "pick" {
+		profile, region = splitPair(choice)
+	}
+	if kind != "region" {
+		env = append(env, "AWS_PROFILE="+profile)
+	}
+	if kind != "profile" {
+		env = append(env, "AWS_REGION="+region, "AWS_DEFAULT_REGION="+region)
+	}
+	return env
+}
+
+// exportLines returns env as shell export statements.
+func exportLines(env []string) string {
+	var b strings.Builder
+	for _, kv := range env {
+		name, value, _ := strings.Cut(kv, "=")
+		if strings.ContainsFunc(value, func(r rune) bool { return !isNameRune(r) }) {
+			value = shellQuote(value)
+		}
+		fmt.Fprintf(&b, "export %s=%s\n", name, value)
+	}
+	return b.String()
+}
+
+func isNameRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.@/", r)
+}
+
+// execShell starts $SHELL with env added, leaving the config files alone.
+// It returns when the shell exits.
+func execShell(env []string) error {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	vars := strings.Join(env, " ")
+	if dryRun {
+		fmt.Fprintf(os.Stderr, "Dry run: would start %s with %s\n", shell, vars)
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Starting %s with %s; exit to return\n", shell, vars)
+	cmd := exec.Command(shell)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = append(os.Environ(), env...)
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			// The shell's own status, e.g. of its last command.
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// clipboardCommands are tried in order to copy to the clipboard.
+var clipboardCommands = [][]string{
+	{"pbcopy"},
+	{"wl-copy"},
+	{"xclip", "-selection", "clipboard"},
+	{"xsel", "--clipboard", "--input"},
+	{"clip.exe"},
+}
+
+// copyExports copies export statements for env to the clipboard, or prints
+// them to stdout when no clipboard command is available.
+func copyExports(env []string) error {
+	exports := exportLines(env)
+	for _, args := range clipboardCommands {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stdin = strings.NewReader(exports)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("copying with %s: %w", args[0], err)
+		}
+		fmt.Fprintf(os.Stderr, "Copied to clipboard:\n%s", exports)
+		return nil
+	}
+	fmt.Print(exports)
+	return nil
+}
+
+// describe prints the details shown in the fzf preview for a choice.
+func describe(kind, choice string) error {
+	if kind != "pick" {
+		return fzfPreview(kind, choice)
+	}
+	profile, region := splitPair(choice)
+	if err := fzfPreview("profile", profile); err != nil {
+		return err
+	}
+	fmt.Println()
+	return fzfPreview("region", region)
+}
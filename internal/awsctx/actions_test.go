@@ -0,0 +1,122 @@
+package awsctx
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestActionEnv(t *testing.T) {
+	tests := []struct {
+		kind, choice string
+		want         []string
+	}{
+		{"profile", "dev", []string{"AWS_PROFILE=dev"}},
+		{"region", "eu-west-1", []string{"AWS_REGION=eu-west-1", "AWS_DEFAULT_REGION=eu-west-1"}},
+		{"pick", "dev eu-west-1", []string{"AWS_PROFILE=dev", "AWS_REGION=eu-west-1", "AWS_DEFAULT_REGION=eu-west-1"}},
+		{"pick", "my team eu-west-1", []string{"AWS_PROFILE=my team", "AWS_REGION=eu-west-1", "AWS_DEFAULT_REGION=eu-west-1"}},
+	}
+	for _, tt := range tests {
+		if got := actionEnv(tt.kind, tt.choice); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("actionEnv(%s, %q) = %v, want %v", tt.kind, tt.choice, got, tt.want)
+		}
+	}
+
+	if keys := actionKeys("region"); contains(keys, "ctrl-r") {
+		t.Errorf("region picker offers ctrl-r: %v", keys)
+	}
+}
+
+func TestExportLines(t *testing.T) {
+	got := exportLines([]string{"AWS_PROFILE=team/dev", "AWS_REGION=it's"})
+	want := "export AWS_PROFILE=team/dev\nexport AWS_REGION='it'\\''s'\n"
+	if got != want {
+		t.Errorf("exportLines:\ngot  %q\nwant %q", got, want)
+	}
+}
+
+func TestParseFzfOutput(t *testing.T) {
+	tests := []struct {
+		out          string
+		pair, expect bool
+		key, choice  string
+	}{
+		{"dev\tdev  111111111111\n", false, false, "", "dev"},
+		{"\ndev\tdev  111111111111\n", false, true, "", "dev"},
+		{"ctrl-e\ndev\tdev\n", false, true, "ctrl-e", "dev"},
+		{"my team\tmy team  111111111111\n", false, false, "", "my team"},
+		{"ctrl-y\ndev\teu-west-1\tdev  eu-west-1\n", true, true, "ctrl-y", "dev eu-west-1"},
+		{"\nmy team\teu-west-1\tmy team  eu-west-1\n", true, true, "", "my team eu-west-1"},
+		{"", false, true, "", ""},
+	}
+	for _, tt := range tests {
+		key, choice := parseFzfOutput(tt.out, tt.pair, tt.expect)
+		if key != tt.key || choice != tt.choice {
+			t.Errorf("parseFzfOutput(%q) = %q, %q, want %q, %q", tt.out, key, choice, tt.key, tt.choice)
+		}
+	}
+}
+
+func TestCopyExports(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PATH", dir)
+	env := []string{"AWS_PROFILE=dev"}
+
+	out := captureStdout(t, func() {
+		if err := copyExports(env); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if out != "export AWS_PROFILE=dev\n" {
+		t.Errorf("without a clipboard command, expected the exports on stdout, got %q", out)
+	}
+
+	copied := filepath.Join(dir, "copied")
+	os.WriteFile(filepath.Join(dir, "wl-copy"), []byte("#!/bin/sh\nread -r line; echo \"$line\" > "+copied+"\n"), 0o755)
+	out = captureStdout(t, func() {
+		if err := copyExports(env); err != nil {
+			t.Fatal(err)
+		}
+	})
+	data, _ := os.ReadFile(copied)
+	if out != "" || string(data) != "export AWS_PROFILE=dev\n" {
+		t.Errorf("expected the exports on the clipboard, got stdout %q, clipboard %q", out, data)
+	}
+}
+
+func TestExecShell(t *testing.T) {
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, "env")
+	shell := filepath.Join(dir, "shell")
+	os.WriteFile(shell, []byte("#!/bin/sh\nenv > "+envFile+"\nexit 3\n"), 0o755)
+	t.Setenv("SHELL", shell)
+
+	if err := execShell(actionEnv("pick", "dev eu-west-1")); err != nil {
+		t.Fatalf("expected the shell's exit status to be ignored, got %v", err)
+	}
+	data, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"AWS_PROFILE=dev\n", "AWS_REGION=eu-west-1\n"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("shell environment missing %q", want)
+		}
+	}
+}
+
+func TestDescribe_Pick(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	out := captureStdout(t, func() {
+		if err := describe("pick", "dev eu-west-1"); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if !strings.Contains(out, "profile  dev") || !strings.Contains(out, "name       Europe (Ireland)") {
+		t.Errorf("expected profile and region details:\n%s", out)
+	}
+}
@@ -3,66 +3,187 @@ package awsctx
 import (
 	"fmt"
 	"os"
-	"os/exec"
 )
 
 var Version = "v0.0.1"
 
+// Run executes the command line in args. Error messages are redacted so
+// secrets never reach the terminal.
 func Run(args []string) error {
-	if _, err := exec.LookPath("aws"); err != nil {
-		return fmt.Errorf("aws CLI is not installed. Install it from https://aws.amazon.com/cli/")
+	return redactError(run(args))
+}
+
+func run(args []string) error {
+	root := newRootCommand()
+	if len(args) > 1 && args[1] == completeCommand {
+		return runComplete(root, args[2:])
+	}
+	in, pos, err := root.parse(args[1:])
+	if err != nil {
+		return err
 	}
 
-	if len(args) < 2 {
-		return ShowStatus()
+	outputFormat = in.String("output")
+	colorMode = in.String("color")
+	dryRun = in.Bool("dry-run")
+	sortOrder = in.String("sort")
+	if p := in.String("config"); p != "" {
+		os.Setenv("AWS_CONFIG_FILE", p)
+	}
+	if p := in.String("credentials"); p != "" {
+		os.Setenv("AWS_SHARED_CREDENTIALS_FILE", p)
 	}
 
-	switch args[1] {
-	case "profile", "p":
-		return handleProfile(args[2:])
-	case "region", "r":
-		return handleRegion(args[2:])
-	case "--fzf-list":
-		if len(args) < 3 {
-			return fmt.Errorf("missing subcommand for --fzf-list")
-		}
-		return fzfList(args[2])
-	case "-h", "--help":
-		printUsage()
+	if in.Bool("version") {
+		fmt.Printf("awsctx %s\n", Version)
 		return nil
-	case "-v", "--version":
-		fmt.Fprintf(os.Stderr, "awsctx %s\n", Version)
+	}
+	if in.Bool("help") || in.cmd.Run == nil {
+		in.cmd.printHelp(os.Stdout)
 		return nil
-	default:
-		return fmt.Errorf("unknown command: %s\nRun 'awsctx --help' for usage", args[1])
 	}
+	return in.cmd.Run(in, pos)
 }
 
-func ShowStatus() error {
-	profile := currentProfile()
-	region := currentRegion()
+// newRootCommand builds the awsctx command tree.
+func newRootCommand() *command {
+	root := &command{
+		Name:  "awsctx",
+		Short: "awsctx - fast AWS profile and region switcher",
+		Long: `
+Switches the [default] profile in ~/.aws/config and ~/.aws/credentials.
+Original [default] is backed up and restored with 'awsctx p default'.
+Without a command, shows the current profile and region.
 
-	fmt.Fprintf(os.Stderr, "profile: %s\n", profile)
-	fmt.Fprintf(os.Stderr, "region:  %s\n", region)
-	return nil
-}
+Listings and values are written to stdout, messages and errors to stderr.`,
+		Flags: []*flagDef{
+			{Name: "output", Short: "o", Arg: "format", Usage: "output format", Choices: outputFormats, Default: "text"},
+			{Name: "color", Arg: "when", Usage: "colorize output, honors NO_COLOR", Choices: colorModes, Default: "auto"},
+			{Name: "config", Arg: "path", Usage: "AWS config file (default $AWS_CONFIG_FILE or ~/.aws/config)"},
+			{Name: "credentials", Arg: "path", Usage: "AWS credentials file (default $AWS_SHARED_CREDENTIALS_FILE or ~/.aws/credentials)"},
+			{Name: "dry-run", Usage: "print a diff of the changes instead of writing files"},
+			{Name: "sort", Arg: "order", Usage: "order of listings (default: file, frecency in pickers)", Choices: sortOrders},
+			{Name: "help", Short: "h", Usage: "show help"},
+			{Name: "version", Short: "v", Usage: "show version"},
+			{Name: "fzf-list", Arg: "kind", Hidden: true, Choices: []string{"profile", "region", "pick"}},
+			{Name: "fzf-preview", Arg: "kind", Hidden: true, Choices: []string{"profile", "region"}},
+		},
+		Examples: []string{
+			"awsctx p dev              # switch to profile dev",
+			"awsctx r -                # switch back to the previous region",
+			"awsctx p -o json          # list profiles as JSON",
+			"awsctx p prod --dry-run   # show what switching to prod would change",
+			"awsctx help region        # show help for the region command",
+		},
+		Run: runRoot,
+	}
 
-func printUsage() {
-	fmt.Fprint(os.Stderr, `USAGE:
-  awsctx                          show current profile and region
-  awsctx p,  profile [<name>]     list or switch AWS profiles
-  awsctx r,  region  [<name>]     list or switch AWS regions
+	root.add(
+		newProfileCommand(),
+		newRegionCommand(),
+		newPickCommand(),
+		newPromptCommand(),
+		&command{
+			Name:  "whoami",
+			Short: "show account and ARN of the active credentials",
+			Long: `
+Calls STS GetCallerIdentity with the active static credentials and caches the
+result per profile; credentials from AWS_ACCESS_KEY_ID are never cached. Set
+AWSCTX_STS_ENDPOINT to use a different endpoint.`,
+			Flags: []*flagDef{
+				{Name: "refresh", Usage: "ignore the cached identity"},
+			},
+			Run: func(in *invocation, args []string) error {
+				if len(args) > 0 {
+					return usageErrorf("whoami takes no arguments")
+				}
+				return whoami(in.Bool("refresh"))
+			},
+		},
+		&command{
+			Name:  "explain",
+			Short: "show which source decides profile, region and credentials",
+			Run: func(in *invocation, args []string) error {
+				if len(args) > 0 {
+					return usageErrorf("explain takes no arguments")
+				}
+				return explain()
+			},
+		},
+		&command{
+			Name:  "doctor",
+			Short: "check the AWS config setup for problems",
+			Long: `
+Validates ~/.aws/config and ~/.aws/credentials: malformed lines, duplicate
+sections, [profile default], [profile x] in the credentials file, missing
+source_profile targets, stale awsctx backups, credentials file permissions,
+shadowing environment variables and expired SSO tokens. Each finding has a
+severity and a suggested fix; --fix applies the safe ones.`,
+			Flags: []*flagDef{
+				{Name: "fix", Usage: "apply safe automatic repairs"},
+			},
+			Run: func(in *invocation, args []string) error {
+				if len(args) > 0 {
+					return usageErrorf("doctor takes no arguments")
+				}
+				return doctor(in.Bool("fix"))
+			},
+		},
+		newCompletionCommand(),
+		&command{
+			Name:  "help",
+			Args:  "[<command>]",
+			Short: "show help for a command",
+			Run: func(in *invocation, args []string) error {
+				cmd := in.cmd.parent
+				for _, name := range args {
+					if cmd = cmd.find(name); cmd == nil {
+						return usageErrorf("unknown command: %s", name)
+					}
+				}
+				cmd.printHelp(os.Stdout)
+				return nil
+			},
+		},
+	)
+	root.find("help").Complete = helpCompletions(root)
+	return root
+}
 
-  awsctx <subcommand> -c          show current value
-  awsctx <subcommand> -           switch to previous value
+func runRoot(in *invocation, args []string) error {
+	if kind := in.String("fzf-preview"); kind != "" {
+		if len(args) != 1 {
+			return usageErrorf("--fzf-preview takes a %s name", kind)
+		}
+		return fzfPreview(kind, args[0])
+	}
+	if len(args) > 0 {
+		return usageErrorf("unknown command: %s\nRun 'awsctx --help' for usage", args[0])
+	}
+	if kind := in.String("fzf-list"); kind != "" {
+		return fzfList(kind)
+	}
+	return ShowStatus()
+}
 
-  awsctx -h, --help               show this message
-  awsctx -v, --version            show version
+func ShowStatus() error {
+	profile := currentProfile()
+	region := currentRegion()
 
-Switches the [default] profile in ~/.aws/config and ~/.aws/credentials.
-Original [default] is backed up and restored with 'awsctx p default'.
+	if structuredOutput() {
+		rec := statusRecord{Profile: profile, Region: region}
+		if region == "(none)" {
+			rec.Region = ""
+		}
+		rec.Account = getProfileAccounts([]string{profile})[profile]
+		return writeOutput(os.Stdout, outputFormat, rec)
+	}
 
-COMPLETIONS (optional):
-  source /path/to/awsctx/shell/awsctx.sh
-`)
+	fmt.Printf("profile: %s\n", profile)
+	fmt.Printf("region:  %s\n", region)
+	// Only known or cached accounts are shown; the status never hits the network.
+	if account := getProfileAccounts([]string{profile})[profile]; account != "" {
+		fmt.Printf("account: %s\n", account)
+	}
+	return nil
 }
@@ -2,7 +2,8 @@ package awsctx
 
 import (
 	"os"
-	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -78,15 +79,16 @@ func TestRun_RegionCurrent(t *testing.T) {
 }
 
 func TestRun_NoAWSCLI(t *testing.T) {
-	origPath := os.Getenv("PATH")
-	os.Setenv("PATH", "")
-	defer os.Setenv("PATH", origPath)
-
-	if _, err := exec.LookPath("aws"); err != nil {
-		err := Run([]string{"awsctx"})
-		if err == nil {
-			t.Error("expected error when aws CLI is missing")
-		}
+	cleanup := setupTestAWS(t, testConfig, testCredentials)
+	defer cleanup()
+	t.Setenv("PATH", "")
+
+	// awsctx only edits config files; the CLI is not required.
+	if err := Run([]string{"awsctx"}); err != nil {
+		t.Errorf("expected no error without aws CLI, got %v", err)
+	}
+	if err := Run([]string{"awsctx", "p", "dev"}); err != nil {
+		t.Errorf("expected switching to work without aws CLI, got %v", err)
 	}
 }
 
@@ -217,3 +219,256 @@ func TestRun_FzfList(t *testing.T) {
 		t.Errorf("expected no error, got %v", err)
 	}
 }
+
+func TestRun_RegionSwitchForProfile(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	err := Run([]string{"awsctx", "r", "eu-north-1", "--profile", "staging"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if r := getProfileRegion("staging"); r != "eu-north-1" {
+		t.Errorf("staging region: expected eu-north-1, got %s", r)
+	}
+	if r := readState("region"); r != "" {
+		t.Errorf("inactive profile must not change region state, got %s", r)
+	}
+
+	// Active profile: both the profile and [default] change.
+	Run([]string{"awsctx", "p", "dev"})
+	err = Run([]string{"awsctx", "r", "--profile=dev", "us-east-2"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if r := getProfileRegion("dev"); r != "us-east-2" {
+		t.Errorf("dev region: expected us-east-2, got %s", r)
+	}
+	if r := getProfileRegion("default"); r != "us-east-2" {
+		t.Errorf("[default] region: expected us-east-2, got %s", r)
+	}
+	if r := currentRegion(); r != "us-east-2" {
+		t.Errorf("current region: expected us-east-2, got %s", r)
+	}
+}
+
+func TestRun_RegionSwitchForProfile_EnvProfile(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	// staging is copied into [default]; AWS_PROFILE=dev must not make dev
+	// look copied.
+	if err := Run([]string{"awsctx", "p", "staging"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	t.Setenv("AWS_PROFILE", "dev")
+
+	if err := Run([]string{"awsctx", "r", "us-east-2", "--profile", "dev"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if r := getProfileRegion("dev"); r != "us-east-2" {
+		t.Errorf("dev region: expected us-east-2, got %s", r)
+	}
+	if r := getProfileRegion("default"); r != "eu-west-1" {
+		t.Errorf("[default] region must be unchanged, got %s", r)
+	}
+	if r := readState("region"); r == "us-east-2" {
+		t.Errorf("region state must be unchanged, got %s", r)
+	}
+}
+
+func TestRun_RegionSwitchForProfile_Invalid(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	if err := Run([]string{"awsctx", "r", "us-east-1", "--profile", "nope"}); err == nil {
+		t.Error("expected error for unknown profile")
+	}
+	if err := Run([]string{"awsctx", "r", "--profile"}); err == nil {
+		t.Error("expected error for missing profile name")
+	}
+}
+
+func TestRun_RegionNearest(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	os.Setenv("AWSCTX_PROBE_ENDPOINT", startProbeServer(t))
+	defer os.Unsetenv("AWSCTX_PROBE_ENDPOINT")
+
+	if err := Run([]string{"awsctx", "r", "--nearest"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if r := readState("region"); !isValidRegion(r) {
+		t.Errorf("expected a valid region in state, got %q", r)
+	}
+	if l := readLatency(); len(l) != len(awsRegions) {
+		t.Errorf("expected %d cached results, got %d", len(awsRegions), len(l))
+	}
+}
+
+func TestRun_Explain(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, testCredentials)
+	defer cleanup()
+
+	if err := Run([]string{"awsctx", "explain"}); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestRun_Whoami(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, testCredentials)
+	defer cleanup()
+
+	srv := startSTSStub(t)
+	t.Setenv("AWSCTX_STS_ENDPOINT", srv.URL)
+
+	Run([]string{"awsctx", "p", "dev"})
+	if err := Run([]string{"awsctx", "whoami"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if id := readIdentity("dev"); id == nil || id.Account != "123456789012" {
+		t.Errorf("expected cached identity for dev, got %+v", id)
+	}
+
+	// Cached: succeeds even with the stub gone.
+	srv.Close()
+	if err := Run([]string{"awsctx", "whoami"}); err != nil {
+		t.Errorf("expected cached result, got %v", err)
+	}
+	if err := Run([]string{"awsctx", "whoami", "--refresh"}); err == nil {
+		t.Error("expected error refreshing without a reachable endpoint")
+	}
+}
+
+func TestRun_WhoamiEnvCredentials(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, testCredentials)
+	defer cleanup()
+
+	srv := startSTSStub(t)
+	t.Setenv("AWSCTX_STS_ENDPOINT", srv.URL)
+
+	Run([]string{"awsctx", "p", "staging"})
+	saveIdentity("staging", &callerIdentity{Account: "222222222222", Arn: "arn:aws:iam::222222222222:user/staging"})
+
+	// The stub answers for AKIADEV: another account than staging's.
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIADEV")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "dev-secret")
+	out := captureStdout(t, func() {
+		if err := Run([]string{"awsctx", "whoami"}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	})
+	if !strings.Contains(out, "account: 123456789012") {
+		t.Errorf("expected the identity of the environment credentials, got:\n%s", out)
+	}
+	if id := readIdentity("staging"); id == nil || id.Account != "222222222222" {
+		t.Errorf("environment credentials must not change staging's cached identity, got %+v", id)
+	}
+}
+
+func TestRun_ProfileSwitchByAccount(t *testing.T) {
+	cleanup := setupTestAWS(t, testAccountsConfig, "")
+	defer cleanup()
+
+	if err := Run([]string{"awsctx", "p", "222222222222"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if p := readState("profile"); p != "sandbox" {
+		t.Errorf("expected sandbox, got %s", p)
+	}
+
+	if err := Run([]string{"awsctx", "p", "111111111111"}); err == nil {
+		t.Error("expected error for account shared by several profiles")
+	}
+	if err := Run([]string{"awsctx", "p", "999999999999"}); err == nil {
+		t.Error("expected error for unknown account")
+	}
+}
+
+func TestRun_ExitCodes(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{[]string{"awsctx", "garbage"}, exitUsage},
+		{[]string{"awsctx", "p", "--bogus"}, exitUsage},
+		{[]string{"awsctx", "p", "a", "b"}, exitUsage},
+		{[]string{"awsctx", "p", "nonexistent"}, exitNotFound},
+		{[]string{"awsctx", "r", "fake-region"}, exitNotFound},
+		{[]string{"awsctx", "r", "-"}, exitNotFound},
+	}
+	for _, tt := range tests {
+		if got := ExitCode(Run(tt.args)); got != tt.want {
+			t.Errorf("Run(%v): exit code = %d, want %d", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestRun_SubcommandHelp(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	out := captureStdout(t, func() {
+		if err := Run([]string{"awsctx", "p", "--help"}); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+	if !strings.Contains(out, "awsctx profile") {
+		t.Errorf("expected profile help, got %q", out)
+	}
+
+	out = captureStdout(t, func() { Run([]string{"awsctx", "help", "region"}) })
+	if !strings.Contains(out, "awsctx region") {
+		t.Errorf("expected region help, got %q", out)
+	}
+	if err := Run([]string{"awsctx", "help", "nope"}); ExitCode(err) != exitUsage {
+		t.Errorf("expected usage error, got %v", err)
+	}
+}
+
+func TestRun_ConfigFlag(t *testing.T) {
+	cleanup := setupTestAWS(t, "", "")
+	defer cleanup()
+
+	path := filepath.Join(t.TempDir(), "other-config")
+	os.WriteFile(path, []byte(testConfig), 0o644)
+
+	if err := Run([]string{"awsctx", "--config", path, "p", "dev"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	ini, _ := loadINI(path)
+	if r := ini.getKeys("default")["region"]; r != "us-west-2" {
+		t.Errorf("expected --config file to be switched, got region %s", r)
+	}
+}
+
+func TestRun_ProfileSwitchCredentialsOnly(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, testCredentialsOnly)
+	defer cleanup()
+
+	if err := Run([]string{"awsctx", "p", "ci-user"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	cini, _ := loadINI(awsCredentialsPath())
+	if k := cini.getKeys("default")["aws_access_key_id"]; k != "AKIACI" {
+		t.Errorf("credentials [default] key: expected AKIACI, got %s", k)
+	}
+
+	out := captureStdout(t, func() { Run([]string{"awsctx", "p"}) })
+	if !strings.Contains(out, "ci-user  (credentials)") {
+		t.Errorf("expected credentials-only marker in listing, got %q", out)
+	}
+
+	// Restoring brings back the original [default] in both files.
+	if err := Run([]string{"awsctx", "p", "default"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	ini, _ := loadINI(awsConfigPath())
+	if r := ini.getKeys("default")["region"]; r != "eu-west-1" {
+		t.Errorf("config [default] region: expected eu-west-1, got %s", r)
+	}
+}
@@ -45,3 +45,29 @@ func saveState(key, value string) {
 	os.MkdirAll(dir, 0o755)
 	os.WriteFile(filepath.Join(dir, "current_"+key), []byte(value), 0o644)
 }
+
+// saveContext records the profile and region in effect after a switch in a
+// single file, so the prompt needs only one read. They are separated by a
+// tab, as profile names may contain spaces.
+func saveContext(profile, region string) {
+	if region == "(none)" {
+		region = ""
+	}
+	dir := cacheDir()
+	os.MkdirAll(dir, 0o755)
+	os.WriteFile(filepath.Join(dir, "context"), []byte(profile+"\t"+region+"\n"), 0o644)
+}
+
+// readContext returns the profile and region saved by saveContext; ok is
+// false if no switch has saved them yet.
+func readContext() (profile, region string, ok bool) {
+	data, err := os.ReadFile(filepath.Join(cacheDir(), "context"))
+	if err != nil {
+		return "", "", false
+	}
+	profile, region, _ = strings.Cut(strings.TrimRight(string(data), "\n"), "\t")
+	if profile == "" {
+		return "", "", false
+	}
+	return profile, region, true
+}
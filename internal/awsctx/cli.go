@@ -0,0 +1,312 @@
+package awsctx
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Exit codes returned by ExitCode.
+const (
+	exitFailure   = 1 // any other error
+	exitUsage     = 2 // bad command line
+	exitNotFound  = 3 // unknown profile, region or account
+	exitCancelled = 4 // interactive selection was aborted
+)
+
+// cliError is an error carrying a specific process exit code.
+type cliError struct {
+	code int
+	err  error
+}
+
+func (e *cliError) Error() string { return e.err.Error() }
+func (e *cliError) Unwrap() error { return e.err }
+
+func usageErrorf(format string, a ...any) error {
+	return &cliError{code: exitUsage, err: fmt.Errorf(format, a...)}
+}
+
+func notFoundErrorf(format string, a ...any) error {
+	return &cliError{code: exitNotFound, err: fmt.Errorf(format, a...)}
+}
+
+func cancelledErrorf(format string, a ...any) error {
+	return &cliError{code: exitCancelled, err: fmt.Errorf(format, a...)}
+}
+
+// ExitCode maps an error returned by Run to a process exit code.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var ce *cliError
+	if errors.As(err, &ce) {
+		return ce.code
+	}
+	return exitFailure
+}
+
+// flagDef describes a command line flag.
+type flagDef struct {
+	Name    string // long name, without dashes
+	Short   string // one-letter alias, without dash
+	Arg     string // value placeholder; empty for boolean flags
+	Usage   string
+	Choices []string // allowed values, if restricted
+	Default string
+	Hidden  bool
+
+	// Complete returns the values offered by shell completion, if they
+	// are not the Choices.
+	Complete func() []completion
+}
+
+// command is a node in the command tree.
+type command struct {
+	Name     string
+	Aliases  []string
+	Args     string // positional argument synopsis, e.g. "[<name> | -]"
+	Short    string
+	Long     string
+	Examples []string
+	Flags    []*flagDef
+	Hidden   bool
+	Commands []*command
+	Run      func(in *invocation, args []string) error
+
+	// Complete returns the candidates for the positional argument that
+	// follows args, for shell completion.
+	Complete func(args []string) []completion
+
+	parent *command
+}
+
+// invocation holds the flag values parsed for one run.
+type invocation struct {
+	cmd   *command
+	flags map[string]string
+}
+
+// String returns the value of a flag, or its default.
+func (in *invocation) String(name string) string {
+	return in.flags[name]
+}
+
+// Bool reports whether a boolean flag was given.
+func (in *invocation) Bool(name string) bool {
+	return in.flags[name] == "true"
+}
+
+// add attaches subcommands to c.
+func (c *command) add(cmds ...*command) *command {
+	for _, sub := range cmds {
+		sub.parent = c
+		c.Commands = append(c.Commands, sub)
+	}
+	return c
+}
+
+// path returns the full command name, e.g. "awsctx profile".
+func (c *command) path() string {
+	if c.parent == nil {
+		return c.Name
+	}
+	return c.parent.path() + " " + c.Name
+}
+
+// find returns the subcommand called name or one of its aliases.
+func (c *command) find(name string) *command {
+	for _, sub := range c.Commands {
+		if sub.Name == name {
+			return sub
+		}
+		for _, a := range sub.Aliases {
+			if a == name {
+				return sub
+			}
+		}
+	}
+	return nil
+}
+
+// lookupFlag finds a flag by long or short name on c or any ancestor, so
+// global flags defined on the root work everywhere.
+func (c *command) lookupFlag(name string) *flagDef {
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		for _, f := range cmd.Flags {
+			if f.Name == name || (f.Short != "" && f.Short == name) {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// parse resolves the subcommand and flags in args (without the program
+// name). Flags may appear anywhere; "--" ends flag parsing and "-" is a
+// positional argument.
+func (c *command) parse(args []string) (*invocation, []string, error) {
+	in := &invocation{cmd: c, flags: make(map[string]string)}
+	var pos []string
+	onlyPos := false
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if onlyPos || arg == "-" || !strings.HasPrefix(arg, "-") {
+			if len(pos) == 0 && !onlyPos {
+				if sub := in.cmd.find(arg); sub != nil {
+					in.cmd = sub
+					continue
+				}
+			}
+			pos = append(pos, arg)
+			continue
+		}
+		if arg == "--" {
+			onlyPos = true
+			continue
+		}
+
+		name, value, hasValue := strings.TrimLeft(arg, "-"), "", false
+		if k, v, ok := strings.Cut(name, "="); ok {
+			name, value, hasValue = k, v, true
+		}
+		// Short flags take a single dash, long flags two.
+		isShort := !strings.HasPrefix(arg, "--")
+		f := in.cmd.lookupFlag(name)
+		if f == nil || (isShort && f.Short != name) || (!isShort && f.Name != name) {
+			return nil, nil, usageErrorf("unknown flag: %s\nRun '%s --help' for usage", arg, in.cmd.path())
+		}
+
+		if f.Arg == "" {
+			if hasValue {
+				return nil, nil, usageErrorf("flag %s does not take a value", arg)
+			}
+			in.flags[f.Name] = "true"
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, nil, usageErrorf("flag %s requires a %s", arg, f.Arg)
+			}
+			i++
+			value = args[i]
+		}
+		if len(f.Choices) > 0 && !contains(f.Choices, value) {
+			return nil, nil, usageErrorf("invalid value %q for --%s (%s)", value, f.Name, strings.Join(f.Choices, "|"))
+		}
+		in.flags[f.Name] = value
+	}
+
+	for cmd := in.cmd; cmd != nil; cmd = cmd.parent {
+		for _, f := range cmd.Flags {
+			if _, ok := in.flags[f.Name]; !ok && f.Default != "" {
+				in.flags[f.Name] = f.Default
+			}
+		}
+	}
+	return in, pos, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// printHelp writes generated help for c.
+func (c *command) printHelp(w io.Writer) {
+	fmt.Fprintf(w, "%s\n\nUSAGE:\n", c.Short)
+	if c.Run != nil {
+		fmt.Fprintf(w, "  %s [flags]%s\n", c.path(), prefixSpace(c.Args))
+	}
+	if len(visibleCommands(c)) > 0 {
+		fmt.Fprintf(w, "  %s <command> [flags] [args]\n", c.path())
+	}
+	if len(c.Aliases) > 0 {
+		fmt.Fprintf(w, "\nALIASES:\n  %s\n", strings.Join(c.Aliases, ", "))
+	}
+	if c.Long != "" {
+		fmt.Fprintf(w, "\n%s\n", strings.TrimSpace(c.Long))
+	}
+
+	if cmds := visibleCommands(c); len(cmds) > 0 {
+		fmt.Fprint(w, "\nCOMMANDS:\n")
+		tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+		for _, sub := range cmds {
+			names := strings.Join(append(append([]string(nil), sub.Aliases...), sub.Name), ", ")
+			fmt.Fprintf(tw, "  %s%s\t%s\n", names, prefixSpace(sub.Args), sub.Short)
+		}
+		tw.Flush()
+	}
+
+	printFlags(w, "FLAGS", c.Flags)
+	if c.parent != nil {
+		var global []*flagDef
+		for p := c.parent; p != nil; p = p.parent {
+			global = append(global, p.Flags...)
+		}
+		printFlags(w, "GLOBAL FLAGS", global)
+	}
+
+	if len(c.Examples) > 0 {
+		fmt.Fprint(w, "\nEXAMPLES:\n")
+		for _, ex := range c.Examples {
+			fmt.Fprintf(w, "  %s\n", ex)
+		}
+	}
+}
+
+func printFlags(w io.Writer, title string, flags []*flagDef) {
+	var visible []*flagDef
+	for _, f := range flags {
+		if !f.Hidden {
+			visible = append(visible, f)
+		}
+	}
+	if len(visible) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\n%s:\n", title)
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	for _, f := range visible {
+		short := "    "
+		if f.Short != "" {
+			short = "-" + f.Short + ", "
+		}
+		arg := ""
+		if f.Arg != "" {
+			arg = " <" + f.Arg + ">"
+		}
+		usage := f.Usage
+		if len(f.Choices) > 0 {
+			usage += " (" + strings.Join(f.Choices, "|") + ")"
+		}
+		fmt.Fprintf(tw, "  %s--%s%s\t%s\n", short, f.Name, arg, usage)
+	}
+	tw.Flush()
+}
+
+func visibleCommands(c *command) []*command {
+	var cmds []*command
+	for _, sub := range c.Commands {
+		if !sub.Hidden {
+			cmds = append(cmds, sub)
+		}
+	}
+	return cmds
+}
+
+func prefixSpace(s string) string {
+	if s == "" {
+		return ""
+	}
+	return " " + s
+}
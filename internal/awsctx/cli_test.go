@@ -0,0 +1,105 @@
+package awsctx
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		args      []string
+		wantCmd   string
+		wantFlags map[string]string
+		wantPos   []string
+	}{
+		{[]string{}, "awsctx", map[string]string{"output": "text", "color": "auto"}, nil},
+		{[]string{"p", "dev"}, "awsctx profile", map[string]string{"output": "text", "color": "auto"}, []string{"dev"}},
+		{[]string{"-o", "json", "profile", "-c"}, "awsctx profile", map[string]string{"output": "json", "color": "auto", "current": "true"}, nil},
+		{[]string{"r", "eu-west-1", "--profile=staging", "--color", "never"}, "awsctx region", map[string]string{"output": "text", "color": "never", "profile": "staging"}, []string{"eu-west-1"}},
+		{[]string{"r", "-"}, "awsctx region", map[string]string{"output": "text", "color": "auto"}, []string{"-"}},
+		{[]string{"p", "--", "-c"}, "awsctx profile", map[string]string{"output": "text", "color": "auto"}, []string{"-c"}},
+		{[]string{"--fzf-list", "region"}, "awsctx", map[string]string{"output": "text", "color": "auto", "fzf-list": "region"}, nil},
+	}
+	for _, tt := range tests {
+		in, pos, err := newRootCommand().parse(tt.args)
+		if err != nil {
+			t.Errorf("parse(%v): unexpected error %v", tt.args, err)
+			continue
+		}
+		if in.cmd.path() != tt.wantCmd {
+			t.Errorf("parse(%v): command = %q, want %q", tt.args, in.cmd.path(), tt.wantCmd)
+		}
+		if !reflect.DeepEqual(in.flags, tt.wantFlags) {
+			t.Errorf("parse(%v): flags = %v, want %v", tt.args, in.flags, tt.wantFlags)
+		}
+		if !reflect.DeepEqual(pos, tt.wantPos) {
+			t.Errorf("parse(%v): args = %v, want %v", tt.args, pos, tt.wantPos)
+		}
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := [][]string{
+		{"--bogus"},
+		{"p", "--nearest"},      // region-only flag
+		{"-output", "json"},     // long flag with one dash
+		{"--o", "json"},         // short flag with two dashes
+		{"-o"},                  // missing value
+		{"--output", "xml"},     // invalid choice
+		{"r", "--nearest=true"}, // bool flag with value
+	}
+	for _, args := range tests {
+		_, _, err := newRootCommand().parse(args)
+		if err == nil {
+			t.Errorf("parse(%v): expected error", args)
+			continue
+		}
+		if code := ExitCode(err); code != exitUsage {
+			t.Errorf("parse(%v): exit code = %d, want %d", args, code, exitUsage)
+		}
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, 0},
+		{errors.New("boom"), exitFailure},
+		{usageErrorf("bad"), exitUsage},
+		{notFoundErrorf("missing"), exitNotFound},
+		{cancelledErrorf("aborted"), exitCancelled},
+	}
+	for _, tt := range tests {
+		if got := ExitCode(tt.err); got != tt.want {
+			t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestPrintHelp(t *testing.T) {
+	root := newRootCommand()
+
+	var b bytes.Buffer
+	root.printHelp(&b)
+	for _, want := range []string{"COMMANDS:", "p, profile", "r, region", "--output <format>", "EXAMPLES:"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("root help missing %q:\n%s", want, b.String())
+		}
+	}
+	if strings.Contains(b.String(), "fzf-list") {
+		t.Error("hidden flags must not be listed")
+	}
+
+	b.Reset()
+	root.find("r").printHelp(&b)
+	for _, want := range []string{"awsctx region [flags] [<name> | -]", "ALIASES:", "--profile <name>", "GLOBAL FLAGS:"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("region help missing %q:\n%s", want, b.String())
+		}
+	}
+}
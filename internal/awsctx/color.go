@@ -0,0 +1,43 @@
+package awsctx
+
+import (
+	"os"
+
+	"golang.org/x/term"
+)
+
+// colorMode is set from the global --color flag: "auto", "always" or "never".
+var colorMode = "auto"
+
+var colorModes = []string{"auto", "always", "never"}
+
+// ANSI escapes for the current entry, the picker selection and the reset.
+const (
+	highlightStyle = "\033[33m\033[40m"
+	selectedStyle  = "\033[7m"
+	resetStyle     = "\033[0m"
+)
+
+// useColor decides per stream whether to emit ANSI escapes. An explicit
+// --color wins; otherwise NO_COLOR disables color and it's enabled only for
+// terminals.
+func useColor(f *os.File) bool {
+	switch colorMode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// highlight marks the current entry of a listing written to f.
+func highlight(f *os.File, s string) string {
+	if !useColor(f) {
+		return s
+	}
+	return highlightStyle + s + resetStyle
+}
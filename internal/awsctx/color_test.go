@@ -0,0 +1,61 @@
+package awsctx
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestUseColor(t *testing.T) {
+	defer func() { colorMode = "auto" }()
+
+	// Test output goes to a pipe or file, never a terminal.
+	f, _ := os.CreateTemp(t.TempDir(), "out")
+	defer f.Close()
+
+	colorMode = "auto"
+	if useColor(f) {
+		t.Error("auto: expected no color for a non-terminal")
+	}
+
+	colorMode = "always"
+	t.Setenv("NO_COLOR", "1")
+	if !useColor(f) {
+		t.Error("always: expected color even with NO_COLOR")
+	}
+
+	colorMode = "never"
+	if useColor(f) {
+		t.Error("never: expected no color")
+	}
+}
+
+func TestRun_ListToStdout(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+	defer func() { colorMode = "auto" }()
+
+	out := captureStdout(t, func() { Run([]string{"awsctx", "p"}) })
+	if out != "default\ndev\nstaging\n" {
+		t.Errorf("expected plain profile list on stdout, got %q", out)
+	}
+
+	out = captureStdout(t, func() { Run([]string{"awsctx", "--color=always", "p"}) })
+	if !strings.Contains(out, "\033[33m\033[40mdefault\033[0m") {
+		t.Errorf("expected highlighted current profile, got %q", out)
+	}
+
+	out = captureStdout(t, func() { Run([]string{"awsctx", "p", "-c"}) })
+	if out != "default\n" {
+		t.Errorf("expected current profile on stdout, got %q", out)
+	}
+
+	out = captureStdout(t, func() { Run([]string{"awsctx", "p", "dev"}) })
+	if out != "" {
+		t.Errorf("switch messages must not go to stdout, got %q", out)
+	}
+
+	if err := Run([]string{"awsctx", "--color", "sometimes"}); err == nil {
+		t.Error("expected error for invalid --color value")
+	}
+}
@@ -0,0 +1,435 @@
+package awsctx
+
+import (
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// completion is one shell completion candidate.
+type completion struct {
+	Value       string
+	Description string
+}
+
+// Completion directives, printed on the first line of __complete output.
+const (
+	compNoFiles = "nofiles" // offer only the listed candidates
+	compFiles   = "files"   // complete file names instead
+)
+
+// completeCommand is the hidden entry point the completion scripts call
+// with the words after "awsctx", the last being the word to complete.
+const completeCommand = "__complete"
+
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+func newCompletionCommand() *command {
+	return &command{
+		Name:  "completion",
+		Args:  "<shell>",
+		Short: "print a shell completion script",
+		Long: `
+Prints the completion script for bash, zsh, fish or powershell. The script asks
+awsctx for candidates, so completions follow your profiles, pins and history.`,
+		Examples: []string{
+			`eval "$(awsctx completion bash)"          # in ~/.bashrc`,
+			`source <(awsctx completion zsh)           # in ~/.zshrc`,
+			`awsctx completion fish | source           # in ~/.config/fish/config.fish`,
+			`awsctx completion powershell | Out-String | Invoke-Expression`,
+		},
+		Complete: func(args []string) []completion {
+			if len(args) > 0 {
+				return nil
+			}
+			return valueCompletions(completionShells)
+		},
+		Run: func(in *invocation, args []string) error {
+			if len(args) != 1 {
+				return usageErrorf("completion takes a shell: %s", strings.Join(completionShells, ", "))
+			}
+			return writeCompletionScript(os.Stdout, args[0])
+		},
+	}
+}
+
+func writeCompletionScript(w io.Writer, shell string) error {
+	scripts := map[string]string{
+		"bash":       bashCompletion,
+		"zsh":        zshCompletion,
+		"fish":       fishCompletion,
+		"powershell": powershellCompletion,
+	}
+	script, ok := scripts[shell]
+	if !ok {
+		return usageErrorf("unsupported shell %q (want %s)", shell, strings.Join(completionShells, ", "))
+	}
+	_, err := io.WriteString(w, script)
+	return err
+}
+
+// runComplete prints the directive and the candidates for words, one
+// "value<TAB>description" line each.
+func runComplete(root *command, words []string) error {
+	directive, cands := complete(root, words)
+	var b strings.Builder
+	b.WriteString(directive + "\n")
+	for _, c := range cands {
+		b.WriteString(c.Value)
+		if c.Description != "" {
+			b.WriteString("\t" + c.Description)
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(os.Stdout, b.String())
+	return err
+}
+
+// complete returns the candidates for the last of words, which are parsed
+// leniently like command.parse: unknown flags are skipped, and --config
+// and --credentials apply to the profiles offered.
+func complete(root *command, words []string) (string, []completion) {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	toComplete := words[len(words)-1]
+
+	cmd := root
+	var pos []string
+	var pending *flagDef // flag waiting for its value
+	onlyPos := false
+	for _, w := range words[:len(words)-1] {
+		switch {
+		case pending != nil:
+			applyCompletionFlag(pending, w)
+			pending = nil
+		case onlyPos || w == "-" || !strings.HasPrefix(w, "-"):
+			if len(pos) == 0 && !onlyPos {
+				if sub := cmd.find(w); sub != nil {
+					cmd = sub
+					continue
+				}
+			}
+			pos = append(pos, w)
+		case w == "--":
+			onlyPos = true
+		default:
+			name, value, hasValue := strings.Cut(strings.TrimLeft(w, "-"), "=")
+			f := cmd.lookupFlag(name)
+			switch {
+			case f == nil || f.Arg == "":
+			case hasValue:
+				applyCompletionFlag(f, value)
+			default:
+				pending = f
+			}
+		}
+	}
+
+	if pending != nil {
+		return flagValueCompletions(pending, "", toComplete)
+	}
+	if !onlyPos && strings.HasPrefix(toComplete, "--") && strings.Contains(toComplete, "=") {
+		name, value, _ := strings.Cut(toComplete[2:], "=")
+		if f := cmd.lookupFlag(name); f != nil && f.Name == name && f.Arg != "" {
+			return flagValueCompletions(f, "--"+name+"=", value)
+		}
+		return compNoFiles, nil
+	}
+
+	var cands []completion
+	if !onlyPos && strings.HasPrefix(toComplete, "-") {
+		cands = flagCompletions(cmd)
+	}
+	if len(pos) == 0 && !onlyPos {
+		for _, sub := range visibleCommands(cmd) {
+			for _, name := range append([]string{sub.Name}, sub.Aliases...) {
+				cands = append(cands, completion{name, sub.Short})
+			}
+		}
+	}
+	if cmd.Complete != nil {
+		cands = append(cands, cmd.Complete(pos)...)
+	}
+	return compNoFiles, filterCompletions(cands, "", toComplete)
+}
+
+// applyCompletionFlag makes the files named on the command line being
+// completed the ones candidates come from.
+func applyCompletionFlag(f *flagDef, value string) {
+	switch f.Name {
+	case "config":
+		os.Setenv("AWS_CONFIG_FILE", value)
+	case "credentials":
+		os.Setenv("AWS_SHARED_CREDENTIALS_FILE", value)
+	}
+}
+
+// flagValueCompletions returns the values of f starting with toComplete,
+// each prefixed with prefix (e.g. "--output="). Paths complete as files.
+func flagValueCompletions(f *flagDef, prefix, toComplete string) (string, []completion) {
+	var cands []completion
+	switch {
+	case f.Arg == "path":
+		return compFiles, nil
+	case f.Complete != nil:
+		cands = f.Complete()
+	default:
+		cands = valueCompletions(f.Choices)
+	}
+	return compNoFiles, filterCompletions(cands, prefix, toComplete)
+}
+
+// flagCompletions returns the visible flags of cmd and its ancestors.
+func flagCompletions(cmd *command) []completion {
+	var cands []completion
+	for c := cmd; c != nil; c = c.parent {
+		for _, f := range c.Flags {
+			if f.Hidden {
+				continue
+			}
+			cands = append(cands, completion{"--" + f.Name, f.Usage})
+			if f.Short != "" {
+				cands = append(cands, completion{"-" + f.Short, f.Usage})
+			}
+		}
+	}
+	return cands
+}
+
+// filterCompletions keeps the candidates starting with toComplete, prefixed
+// with prefix, and drops repeated values.
+func filterCompletions(cands []completion, prefix, toComplete string) []completion {
+	var out []completion
+	seen := make(map[string]bool)
+	for _, c := range cands {
+		if !strings.HasPrefix(c.Value, toComplete) || seen[c.Value] {
+			continue
+		}
+		seen[c.Value] = true
+		out = append(out, completion{prefix + c.Value, c.Description})
+	}
+	return out
+}
+
+func valueCompletions(values []string) []completion {
+	cands := make([]completion, len(values))
+	for i, v := range values {
+		cands[i] = completion{Value: v}
+	}
+	return cands
+}
+
+// profileCompletions returns the profiles in picker order, described by
+// their listing columns (account, account name, pin).
+func profileCompletions() []completion {
+	entries, err := getProfileEntries()
+	if err != nil {
+		return nil
+	}
+	entries = sortedProfiles(entries, sortFor(true))
+	cands := make([]completion, len(entries))
+	for i, label := range profileLabels(entries) {
+		name := entries[i].Name
+		desc := strings.Join(strings.Fields(strings.TrimPrefix(label, name)), " ")
+		cands[i] = completion{name, desc}
+	}
+	return cands
+}
+
+// accountCompletions returns the known account IDs, described by the
+// profiles using them.
+func accountCompletions() []completion {
+	entries, err := getProfileEntries()
+	if err != nil {
+		return nil
+	}
+	profiles := make([]string, len(entries))
+	for i, e := range entries {
+		profiles[i] = e.Name
+	}
+	accounts := getProfileAccounts(profiles)
+
+	var ids []string
+	byAccount := make(map[string][]string)
+	for _, p := range profiles {
+		if id := accounts[p]; id != "" {
+			if byAccount[id] == nil {
+				ids = append(ids, id)
+			}
+			byAccount[id] = append(byAccount[id], p)
+		}
+	}
+	cands := make([]completion, len(ids))
+	for i, id := range ids {
+		cands[i] = completion{id, strings.Join(byAccount[id], ", ")}
+	}
+	return cands
+}
+
+// regionCompletions returns the regions in picker order, described by
+// their display names.
+func regionCompletions() []completion {
+	regions := sortedRegions(sortFor(true))
+	pins := pinnedSet("region")
+	cands := make([]completion, len(regions))
+	for i, r := range regions {
+		desc := regionNames[r]
+		if pins[r] {
+			desc += " " + pinnedMarker
+		}
+		cands[i] = completion{r, desc}
+	}
+	return cands
+}
+
+// pinCompletions returns the pinned profiles or regions (kind).
+func pinCompletions(kind string) []completion {
+	return valueCompletions(readPins(kind))
+}
+
+// commandCompletions returns the visible subcommands of cmd.
+func commandCompletions(cmd *command) []completion {
+	var cands []completion
+	for _, sub := range visibleCommands(cmd) {
+		cands = append(cands, completion{sub.Name, sub.Short})
+	}
+	return cands
+}
+
+// firstArg adapts a completion function to commands taking a single
+// positional argument.
+func firstArg(fn func() []completion) func(args []string) []completion {
+	return func(args []string) []completion {
+		if len(args) > 0 {
+			return nil
+		}
+		return fn()
+	}
+}
+
+// helpCompletions completes the command path after "awsctx help".
+func helpCompletions(root *command) func(args []string) []completion {
+	return func(args []string) []completion {
+		cmd := root
+		for _, name := range args {
+			if cmd = cmd.find(name); cmd == nil {
+				return nil
+			}
+		}
+		return slices.DeleteFunc(commandCompletions(cmd), func(c completion) bool {
+			return c.Value == "help"
+		})
+	}
+}
+
+// The completion scripts pass the words up to the cursor to __complete and
+// offer its candidates; see runComplete for the format.
+
+const bashCompletion = `# bash completion for awsctx
+
+_awsctx() {
+    local line=${COMP_LINE:0:COMP_POINT}
+    local -a words
+    read -ra words <<< "$line"
+    [[ $line == *[[:space:]] ]] && words+=("")
+    local cur=${words[${#words[@]}-1]}
+
+    local out
+    out=$(command awsctx __complete "${words[@]:1}" 2>/dev/null) || return
+    local directive=${out%%$'\n'*}
+    COMPREPLY=()
+    if [[ $directive == files ]]; then
+        compopt -o filenames 2>/dev/null
+        COMPREPLY=($(compgen -f -- "${COMP_WORDS[COMP_CWORD]}"))
+        return
+    fi
+
+    local cand
+    while IFS= read -r cand; do
+        [[ -n $cand ]] && COMPREPLY+=("${cand%%$'\t'*}")
+    done <<< "${out#"$directive"}"
+
+    # bash splits words at = and :; drop the part it already has.
+    local word=${COMP_WORDS[COMP_CWORD]}
+    if [[ $cur != "$word" && $cur == *"$word" ]]; then
+        local done=${cur%"$word"}
+        COMPREPLY=("${COMPREPLY[@]#"$done"}")
+    fi
+}
+complete -F _awsctx awsctx
+`
+
+const zshCompletion = `#compdef awsctx
+# zsh completion for awsctx
+
+_awsctx() {
+    local out directive line
+    local -a lines cands
+    out=$(command awsctx __complete "${(@)words[2,CURRENT]}" 2>/dev/null) || return
+    lines=("${(@f)out}")
+    directive=$lines[1]
+    if [[ $directive == files ]]; then
+        _files
+        return
+    fi
+    for line in "${(@)lines[2,-1]}"; do
+        local value=${line%%$'\t'*}
+        value=${value//:/\\:}
+        if [[ $line == *$'\t'* ]]; then
+            cands+=("$value:${line#*$'\t'}")
+        else
+            cands+=("$value")
+        fi
+    done
+    _describe -V awsctx cands
+}
+
+if [[ $funcstack[1] == _awsctx ]]; then
+    _awsctx "$@"
+else
+    compdef _awsctx awsctx
+fi
+`
+
+const fishCompletion = `# fish completion for awsctx
+
+function __awsctx_complete
+    set -l args (commandline -opc)[2..-1] (commandline -ct)
+    set -l out (command awsctx __complete $args 2>/dev/null); or return
+    if test "$out[1]" = files
+        __fish_complete_path (commandline -ct)
+        return
+    end
+    printf '%s\n' $out[2..-1]
+end
+
+complete -c awsctx -f -k -a '(__awsctx_complete)'
+`
+
+const powershellCompletion = `# powershell completion for awsctx
+
+Register-ArgumentCompleter -Native -CommandName awsctx -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+
+    $words = @($commandAst.CommandElements |
+        Where-Object { $_.Extent.StartOffset -lt $cursorPosition } |
+        Select-Object -Skip 1 |
+        ForEach-Object { $_.ToString() })
+    if ($wordToComplete -eq '') {
+        # Older versions drop empty arguments to native commands.
+        if ($PSVersionTable.PSVersion -lt [version]'7.3.0') { $words += '""' } else { $words += '' }
+    }
+
+    $out = @(& awsctx __complete @words 2>$null)
+    if ($out.Count -eq 0 -or $out[0] -eq 'files') {
+        return
+    }
+    $out | Select-Object -Skip 1 | ForEach-Object {
+        $value, $description = $_ -split "` + "`t" + `", 2
+        if (-not $description) { $description = $value }
+        [System.Management.Automation.CompletionResult]::new($value, $value, 'ParameterValue', $description)
+    }
+}
+`
@@ -0,0 +1,114 @@
+package awsctx
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func completionValues(t *testing.T, words ...string) (string, []string) {
+	t.Helper()
+	directive, cands := complete(newRootCommand(), words)
+	var values []string
+	for _, c := range cands {
+		values = append(values, c.Value)
+	}
+	return directive, values
+}
+
+func TestComplete(t *testing.T) {
+	cleanup := setupTestAWS(t, testAccountsConfig, testCredentials)
+	defer cleanup()
+
+	tests := []struct {
+		words []string
+		want  []string
+	}{
+		{[]string{"pro"}, []string{"profile", "prompt"}},
+		{[]string{"p", "prod"}, []string{"prod", "prod-ro"}},
+		{[]string{"p", "1111"}, []string{"111111111111"}},
+		{[]string{"p", "--p"}, []string{"--pinned"}},
+		{[]string{"p", "-"}, []string{"--current", "-c", "--pinned", "--output", "-o", "--color", "--config", "--credentials", "--dry-run", "--sort", "--help", "-h", "--version", "-v", "-"}},
+		{[]string{"p", "un"}, []string{"unpin"}},
+		{[]string{"r", "eu-west-"}, []string{"eu-west-1", "eu-west-2", "eu-west-3"}},
+		{[]string{"r", "--profile", "sa"}, []string{"sandbox"}},
+		{[]string{"r", "--profile=sa"}, []string{"--profile=sandbox"}},
+		{[]string{"-o", "y"}, []string{"yaml"}},
+		{[]string{"--output=t"}, []string{"--output=text", "--output=tsv"}},
+		{[]string{"pick", "default", "sa-"}, []string{"sa-east-1"}},
+		{[]string{"pick", "default", "sa-east-1", ""}, nil},
+		{[]string{"help", "re"}, []string{"region"}},
+		{[]string{"help", "p", "p"}, []string{"pin"}},
+		{[]string{"completion", "f"}, []string{"fish"}},
+		{[]string{"p", "--", "-"}, []string{"-"}},
+		{[]string{"whoami", ""}, nil},
+	}
+	for _, tt := range tests {
+		directive, got := completionValues(t, tt.words...)
+		if directive != compNoFiles || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("complete(%q) = %s %v, want %v", tt.words, directive, got, tt.want)
+		}
+	}
+
+	if directive, _ := completionValues(t, "--config", ""); directive != compFiles {
+		t.Errorf("expected file completion for --config, got %s", directive)
+	}
+}
+
+func TestComplete_Descriptions(t *testing.T) {
+	cleanup := setupTestAWS(t, testAccountsConfig, testCredentials)
+	defer cleanup()
+
+	_, cands := complete(newRootCommand(), []string{"p", "prod-r"})
+	if len(cands) != 1 || cands[0].Description != "111111111111" {
+		t.Errorf("expected prod-ro described by its account, got %+v", cands)
+	}
+	_, cands = complete(newRootCommand(), []string{"r", "eu-west-1"})
+	if len(cands) != 1 || cands[0].Description != "Europe (Ireland)" {
+		t.Errorf("expected eu-west-1 described by its name, got %+v", cands)
+	}
+}
+
+func TestComplete_ConfigFlag(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	other := filepath.Join(t.TempDir(), "config")
+	os.WriteFile(other, []byte("[profile elsewhere]\n"), 0o644)
+	if _, got := completionValues(t, "--config", other, "p", ""); !contains(got, "elsewhere") || contains(got, "staging") {
+		t.Errorf("expected the profiles of --config, got %v", got)
+	}
+}
+
+func TestRun_Complete(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	out := captureStdout(t, func() {
+		if err := Run([]string{"awsctx", "__complete", "r", "--nearest", "us-west-"}); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+	want := "nofiles\nus-west-1\tUS West (N. California)\nus-west-2\tUS West (Oregon)\n"
+	if out != want {
+		t.Errorf("__complete output:\ngot  %q\nwant %q", out, want)
+	}
+}
+
+func TestCompletionScripts(t *testing.T) {
+	for _, shell := range completionShells {
+		var b bytes.Buffer
+		if err := writeCompletionScript(&b, shell); err != nil {
+			t.Fatalf("%s: %v", shell, err)
+		}
+		if !strings.Contains(b.String(), "awsctx __complete") {
+			t.Errorf("%s script does not call __complete", shell)
+		}
+	}
+	if err := writeCompletionScript(&bytes.Buffer{}, "tcsh"); ExitCode(err) != exitUsage {
+		t.Errorf("expected a usage error for an unknown shell, got %v", err)
+	}
+}
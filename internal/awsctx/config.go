@@ -1,19 +1,13 @@
 package awsctx
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
+	"slices"
 	"strings"
 )
 
-var (
-	profileHeaderRe = regexp.MustCompile(`^\[profile\s+(.+)\]$`)
-	defaultHeaderRe = regexp.MustCompile(`^\[default\]$`)
-)
-
 func awsConfigPath() string {
 	if p := os.Getenv("AWS_CONFIG_FILE"); p != "" {
 		return p
@@ -30,39 +24,149 @@ func awsCredentialsPath() string {
 	return filepath.Join(home, ".aws", "credentials")
 }
 
-// getProfiles parses ~/.aws/config and returns profile names.
-func getProfiles() ([]string, error) {
-	f, err := os.Open(awsConfigPath())
+// dryRun is set from the global --dry-run flag. Mutating commands then print
+// a diff of the files instead of writing them.
+var dryRun bool
+
+// writeINI saves f, or with --dry-run prints the would-be changes as a
+// unified diff with secrets masked.
+func writeINI(f *iniFile) error {
+	if dryRun {
+		fmt.Print(redactDiff(unifiedDiff(f.path, f.orig, f.lines)))
+		return nil
+	}
+	defer resetConfig()
+	return f.save()
+}
+
+// profileSection returns the config file section name for a profile.
+func profileSection(name string) string {
+	if name == "default" {
+		return "default"
+	}
+	return "profile " + name
+}
+
+// configProfileName returns the profile a config file section defines. Like
+// the AWS CLI, [profile default] defines the default profile.
+func configProfileName(section string) (string, bool) {
+	if section == "default" {
+		return "default", true
+	}
+	if name, ok := strings.CutPrefix(section, "profile "); ok {
+		return name, true
+	}
+	return "", false
+}
+
+// configSection returns the config section holding a profile's settings;
+// [profile default] stands in for a missing [default].
+func configSection(ini *iniFile, name string) string {
+	if name == "default" && !ini.hasSection("default") && ini.hasSection("profile default") {
+		return "profile default"
+	}
+	return profileSection(name)
+}
+
+// noDefaultMarker is the body of the backup when there was no [default] to
+// back up, only [profile default], so that restoring deletes the [default]
+// the switch created instead of keeping another profile's settings in it.
+const noDefaultMarker = "# awsctx: there was no [default] before the switch"
+
+// backupNoDefault creates the backup recording that there was no [default].
+func backupNoDefault(ini *iniFile) {
+	ini.replaceSection("_awsctx_original_default", nil)
+	start, _, _ := ini.sectionRange("_awsctx_original_default")
+	ini.lines = slices.Insert(ini.lines, start+1, noDefaultMarker)
+	ini.dropIndex()
+}
+
+// hadNoDefault reports whether the backup records that there was no
+// [default], see backupNoDefault.
+func hadNoDefault(ini *iniFile) bool {
+	start, end, found := ini.sectionRange("_awsctx_original_default")
+	return found && slices.Contains(ini.lines[start+1:end+1], noDefaultMarker)
+}
+
+// defaultProfileSection returns the section holding the default profile's
+// own settings. While another profile is active, the original [default]
+// lives in the backup, or in [profile default] if there was none.
+func defaultProfileSection(ini *iniFile) string {
+	switch {
+	case hadNoDefault(ini):
+		return "profile default"
+	case ini.hasSection("_awsctx_original_default"):
+		return "_awsctx_original_default"
+	}
+	return configSection(ini, "default")
+}
+
+// profileKeys returns a profile's settings as the user defined them.
+func profileKeys(ini *iniFile, name string) map[string]string {
+	if name == "default" {
+		return ini.getKeys(defaultProfileSection(ini))
+	}
+	return ini.getKeys(configSection(ini, name))
+}
+
+// profileEntry is a profile and the files that define it.
+type profileEntry struct {
+	Name          string
+	InConfig      bool
+	InCredentials bool
+}
+
+// getProfileEntries lists the profiles of ~/.aws/config followed by those
+// defined only in ~/.aws/credentials; the AWS CLI accepts both.
+func getProfileEntries() ([]profileEntry, error) {
+	cfg, err := loadConfig()
 	if err != nil {
-		return nil, fmt.Errorf("cannot read AWS config: %w", err)
-	}
-	defer f.Close()
-
-	var profiles []string
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if defaultHeaderRe.MatchString(line) {
-			profiles = append(profiles, "default")
-		} else if m := profileHeaderRe.FindStringSubmatch(line); m != nil {
-			profiles = append(profiles, m[1])
-		}
+		return nil, err
+	}
+	if len(cfg.entries) == 0 && cfg.missing != nil {
+		return nil, fmt.Errorf("cannot read AWS config: %w", cfg.missing)
 	}
-	return profiles, scanner.Err()
+	return cfg.entries, nil
 }
 
-// profileExists checks whether a profile is defined in AWS config.
-func profileExists(name string) bool {
-	profiles, err := getProfiles()
+// getProfiles returns the names of all profiles in config and credentials.
+func getProfiles() ([]string, error) {
+	entries, err := getProfileEntries()
 	if err != nil {
-		return false
+		return nil, err
 	}
-	for _, p := range profiles {
-		if p == name {
-			return true
+	profiles := make([]string, len(entries))
+	for i, e := range entries {
+		profiles[i] = e.Name
+	}
+	return profiles, nil
+}
+
+// findProfileEntry returns the entry for name. Unknown profiles, e.g. from
+// AWS_PROFILE, are reported as config profiles.
+func findProfileEntry(name string) profileEntry {
+	entries, _ := getProfileEntries()
+	for _, e := range entries {
+		if e.Name == name {
+			return e
 		}
 	}
-	return false
+	return profileEntry{Name: name, InConfig: true}
+}
+
+// profileExists checks whether a profile is defined in AWS config or credentials.
+func profileExists(name string) bool {
+	cfg, err := loadConfig()
+	return err == nil && cfg.hasProfile(name)
+}
+
+// copiedProfile returns the profile awsctx copied into [default], ignoring
+// AWS_PROFILE: the profile whose settings [default] holds.
+func copiedProfile() string {
+	if p := readState("profile"); p != "" {
+		return p
+	}
+	return "default"
 }
 
 // currentProfile returns the currently active AWS profile.
@@ -77,8 +181,9 @@ func currentProfile() string {
 	return "default"
 }
 
-// currentRegion returns the currently active AWS region.
-// Checks: env var > state file > config file > "(none)".
+// currentRegion returns the currently active AWS region, in the order
+// explain reports (see regionChain).
+// Checks: env var > config file region of the effective profile > "(none)".
 func currentRegion() string {
 	if r := os.Getenv("AWS_REGION"); r != "" {
 		return r
@@ -86,11 +191,7 @@ func currentRegion() string {
 	if r := os.Getenv("AWS_DEFAULT_REGION"); r != "" {
 		return r
 	}
-	if r := readState("region"); r != "" {
-		return r
-	}
-	// Fall back to config file region for current profile
-	if r := getProfileRegion(currentProfile()); r != "" {
+	if r := getProfileRegion(effectiveProfile()); r != "" {
 		return r
 	}
 	return "(none)"
@@ -98,75 +199,79 @@ func currentRegion() string {
 
 // getProfileRegion returns the region configured for a specific profile in ~/.aws/config.
 func getProfileRegion(name string) string {
-	f, err := os.Open(awsConfigPath())
+	cfg, err := loadConfig()
 	if err != nil {
 		return ""
 	}
-	defer f.Close()
-
-	inTarget := false
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-
-		if name == "default" && defaultHeaderRe.MatchString(line) {
-			inTarget = true
-			continue
-		}
-		if m := profileHeaderRe.FindStringSubmatch(line); m != nil {
-			inTarget = (m[1] == name)
-			continue
-		}
-		if defaultHeaderRe.MatchString(line) && name != "default" {
-			inTarget = false
-			continue
-		}
+	return cfg.config.getKeys(configSection(cfg.config, name))["region"]
+}
 
-		if inTarget && strings.HasPrefix(line, "region") {
-			parts := strings.SplitN(line, "=", 2)
-			if len(parts) == 2 {
-				return strings.TrimSpace(parts[1])
-			}
-		}
+func switchProfileInConfig(name string) error {
+	ini, err := switchedConfig(name)
+	if err != nil {
+		return err
 	}
-	return ""
+	return writeINI(ini)
 }
-func switchProfileInConfig(name string) error {
-	ini, err := loadINI(awsConfigPath())
+
+// switchContextInConfig switches [default] to profile and sets its region
+// with a single write of the config file.
+func switchContextInConfig(profile, region string) error {
+	ini, err := switchedConfig(profile)
 	if err != nil {
 		return err
 	}
+	ini.setKey("default", "region", region)
+	return writeINI(ini)
+}
+
+// switchedConfig returns the config file with profile name copied into
+// [default], without saving it.
+func switchedConfig(name string) (*iniFile, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	ini := cfg.editConfig()
 
 	// One-time backup of original [default]
 	if !ini.hasSection("_awsctx_original_default") {
 		if ini.hasSection("default") {
 			ini.copySection("default", "_awsctx_original_default")
+		} else if name != "default" && ini.hasSection("profile default") {
+			backupNoDefault(ini)
 		}
 	}
 
 	if name == "default" {
 		// Restore original
-		if ini.hasSection("_awsctx_original_default") {
+		if hadNoDefault(ini) {
+			ini.deleteSection("default")
+			ini.deleteSection("_awsctx_original_default")
+		} else if ini.hasSection("_awsctx_original_default") {
 			ini.copySection("_awsctx_original_default", "default")
 			ini.deleteSection("_awsctx_original_default")
 		}
-	} else {
+	} else if srcSection := "profile " + name; ini.hasSection(srcSection) {
 		// Copy [profile <name>] → [default]
-		srcSection := "profile " + name
-		if !ini.hasSection(srcSection) {
-			return fmt.Errorf("profile %q not found in %s", name, awsConfigPath())
-		}
 		ini.copySection(srcSection, "default")
+	} else if cfg.credentials.hasSection(name) {
+		// Credentials-only profile: like the AWS CLI, it has no config
+		// settings, so [default] must not keep the previous profile's.
+		ini.replaceSection("default", map[string]string{})
+	} else {
+		return nil, fmt.Errorf("profile %q not found in %s", name, awsConfigPath())
 	}
 
-	return ini.save()
+	return ini, nil
 }
 
 func switchProfileInCredentials(name string) error {
-	ini, err := loadINI(awsCredentialsPath())
+	cfg, err := loadConfig()
 	if err != nil {
 		return err
 	}
+	ini := cfg.editCredentials()
 
 	// If credentials file is empty/missing, skip silently
 	if len(ini.lines) == 0 {
@@ -192,14 +297,41 @@ func switchProfileInCredentials(name string) error {
 		ini.copySection(name, "default")
 	}
 
-	return ini.save()
+	return writeINI(ini)
 }
 
 func switchRegionInConfig(region string) error {
-	ini, err := loadINI(awsConfigPath())
+	cfg, err := loadConfig()
 	if err != nil {
 		return err
 	}
+	ini := cfg.editConfig()
 	ini.setKey("default", "region", region)
-	return ini.save()
+	return writeINI(ini)
+}
+
+// switchRegionInProfile sets the region key on a named profile. When active
+// is true the profile is the one currently copied into [default], so
+// [default] is updated as well.
+func switchRegionInProfile(profile, region string, active bool) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	ini := cfg.editConfig()
+
+	section := configSection(ini, profile)
+	// While another profile is active, the real default lives elsewhere.
+	if profile == "default" && !active {
+		section = defaultProfileSection(ini)
+	}
+	if !ini.hasSection(section) {
+		return fmt.Errorf("profile %q not found in %s", profile, awsConfigPath())
+	}
+
+	ini.setKey(section, "region", region)
+	if active && section != "default" && ini.hasSection("default") {
+		ini.setKey("default", "region", region)
+	}
+	return writeINI(ini)
 }
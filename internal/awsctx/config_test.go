@@ -3,12 +3,13 @@ package awsctx
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
 // setupTestAWS creates a temp AWS config file and isolated cache dir.
 // Returns a cleanup function that restores original env vars.
-func setupTestAWS(t *testing.T, config, credentials string) func() {
+func setupTestAWS(t testing.TB, config, credentials string) func() {
 	t.Helper()
 	dir := t.TempDir()
 
@@ -219,3 +220,241 @@ func TestSwitchProfileInConfig_BackupOnlyOnce(t *testing.T) {
 		t.Errorf("backup should have original region eu-west-1, got %s", backupKeys["region"])
 	}
 }
+
+func TestSwitchRegionInProfile(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	if err := switchRegionInProfile("staging", "ap-south-1", false); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	ini, _ := loadINI(awsConfigPath())
+	if r := ini.getKeys("profile staging")["region"]; r != "ap-south-1" {
+		t.Errorf("staging region: expected ap-south-1, got %s", r)
+	}
+	if r := ini.getKeys("default")["region"]; r != "eu-west-1" {
+		t.Errorf("inactive profile must not touch [default], got %s", r)
+	}
+}
+
+func TestSwitchRegionInProfile_Active(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	switchProfileInConfig("dev")
+	if err := switchRegionInProfile("dev", "ca-central-1", true); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	ini, _ := loadINI(awsConfigPath())
+	if r := ini.getKeys("profile dev")["region"]; r != "ca-central-1" {
+		t.Errorf("dev region: expected ca-central-1, got %s", r)
+	}
+	if r := ini.getKeys("default")["region"]; r != "ca-central-1" {
+		t.Errorf("[default] region: expected ca-central-1, got %s", r)
+	}
+}
+
+func TestSwitchRegionInProfile_DefaultWhileInactive(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	switchProfileInConfig("dev")
+	if err := switchRegionInProfile("default", "sa-east-1", false); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	ini, _ := loadINI(awsConfigPath())
+	if r := ini.getKeys("_awsctx_original_default")["region"]; r != "sa-east-1" {
+		t.Errorf("backup region: expected sa-east-1, got %s", r)
+	}
+	if r := ini.getKeys("default")["region"]; r != "us-west-2" {
+		t.Errorf("[default] should still hold dev's region, got %s", r)
+	}
+}
+
+func TestSwitchRegionInProfile_Missing(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	if err := switchRegionInProfile("nonexistent", "us-east-1", false); err == nil {
+		t.Error("expected error for missing profile")
+	}
+}
+
+const testCredentialsOnly = `[default]
+aws_access_key_id = AKIADEFAULT
+aws_secret_access_key = default-secret
+
+[dev]
+aws_access_key_id = AKIADEV
+aws_secret_access_key = dev-secret
+
+[ci-user]
+aws_access_key_id = AKIACI
+aws_secret_access_key = ci-secret
+
+[profile broken]
+aws_access_key_id = AKIABROKEN
+`
+
+func TestGetProfileEntries(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, testCredentialsOnly)
+	defer cleanup()
+
+	entries, err := getProfileEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []profileEntry{
+		{Name: "default", InConfig: true, InCredentials: true},
+		{Name: "dev", InConfig: true, InCredentials: true},
+		{Name: "staging", InConfig: true},
+		{Name: "ci-user", InCredentials: true},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("getProfileEntries:\ngot  %+v\nwant %+v", entries, want)
+	}
+}
+
+func TestGetProfileEntries_HeaderVariants(t *testing.T) {
+	config := `[ default ] # main account
+region = us-east-1
+
+[profile  dev]
+region = us-west-2
+
+[ profile "staging" ]
+region = eu-west-1
+
+[sso-session corp]
+sso_region = us-east-1
+`
+	credentials := `[ dev ]
+aws_access_key_id = AKIADEV
+
+[profile ci]
+aws_access_key_id = AKIACI
+`
+	cleanup := setupTestAWS(t, config, credentials)
+	defer cleanup()
+
+	entries, err := getProfileEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []profileEntry{
+		{Name: "default", InConfig: true},
+		{Name: "dev", InConfig: true, InCredentials: true},
+		{Name: "staging", InConfig: true},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("getProfileEntries:\ngot  %+v\nwant %+v", entries, want)
+	}
+
+	for name, region := range map[string]string{"default": "us-east-1", "dev": "us-west-2", "staging": "eu-west-1"} {
+		if got := getProfileRegion(name); got != region {
+			t.Errorf("getProfileRegion(%q) = %q, want %q", name, got, region)
+		}
+	}
+}
+
+func TestProfileDefaultSection(t *testing.T) {
+	config := `[profile default]
+region = ap-south-1
+
+[profile dev]
+region = us-west-2
+`
+	cleanup := setupTestAWS(t, config, "")
+	defer cleanup()
+
+	profiles, err := getProfiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(profiles, []string{"default", "dev"}) {
+		t.Errorf("unexpected profiles %v", profiles)
+	}
+	if got := getProfileRegion("default"); got != "ap-south-1" {
+		t.Errorf("getProfileRegion(default) = %q, want ap-south-1", got)
+	}
+}
+
+func TestProfileDefaultSection_SwitchRoundTrip(t *testing.T) {
+	config := `[profile default]
+region = us-east-1
+
+[profile dev]
+region = eu-west-1
+`
+	cleanup := setupTestAWS(t, config, "")
+	defer cleanup()
+
+	if err := Run([]string{"awsctx", "p", "dev"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, _ := loadConfig()
+	if keys := cfg.profileKeys("default"); keys["region"] != "us-east-1" || len(keys) != 1 {
+		t.Errorf("while dev is copied, expected default's own settings, got %v", keys)
+	}
+	if f := checkProfileDefault(); len(f) != 0 {
+		t.Errorf("the [default] of a switch is not a conflict, got %+v", f)
+	}
+	if err := Run([]string{"awsctx", "r", "us-east-2", "--profile", "default"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Run([]string{"awsctx", "p", "default"}); err != nil {
+		t.Fatal(err)
+	}
+	want := `[profile default]
+region = us-east-2
+
+[profile dev]
+region = eu-west-1
+`
+	if data, _ := os.ReadFile(awsConfigPath()); string(data) != want {
+		t.Errorf("expected the original sections back:\n%s", data)
+	}
+	if r := currentRegion(); r != "us-east-2" {
+		t.Errorf("current region: expected us-east-2, got %s", r)
+	}
+}
+
+func TestGetProfiles_NoConfigFile(t *testing.T) {
+	cleanup := setupTestAWS(t, "", testCredentialsOnly)
+	defer cleanup()
+	os.Remove(awsConfigPath())
+
+	profiles, err := getProfiles()
+	if err != nil {
+		t.Fatalf("expected credentials profiles without a config file, got %v", err)
+	}
+	if !reflect.DeepEqual(profiles, []string{"default", "dev", "ci-user"}) {
+		t.Errorf("unexpected profiles %v", profiles)
+	}
+
+	os.Remove(awsCredentialsPath())
+	if _, err := getProfiles(); err == nil {
+		t.Error("expected error when neither file exists")
+	}
+}
+
+func TestSwitchProfileInConfig_CredentialsOnly(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, testCredentialsOnly)
+	defer cleanup()
+
+	if err := switchProfileInConfig("ci-user"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	ini, _ := loadINI(awsConfigPath())
+	if keys := ini.getKeys("default"); len(keys) != 0 {
+		t.Errorf("expected empty [default] for a credentials-only profile, got %v", keys)
+	}
+	if r := ini.getKeys("_awsctx_original_default")["region"]; r != "eu-west-1" {
+		t.Errorf("expected original default backed up, got region %q", r)
+	}
+}
@@ -0,0 +1,112 @@
+package awsctx
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	a := strings.Split("[default]\nregion = eu-west-1\noutput = json\n\n[profile dev]\nregion = us-west-2\noutput = yaml", "\n")
+	b := strings.Split("[default]\noutput = yaml\nregion = us-west-2\n\n[profile dev]\nregion = us-west-2\noutput = yaml", "\n")
+
+	want := `--- a/config
++++ b/config
+@@ -1,6 +1,6 @@
+ [default]
+-region = eu-west-1
+-output = json
++output = yaml
++region = us-west-2
+ 
+ [profile dev]
+ region = us-west-2
+`
+	if got := unifiedDiff("config", a, b); got != want {
+		t.Errorf("diff mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	got := unifiedDiff("/home/u/.aws/config", a, b)
+	if header := "--- /home/u/.aws/config\n+++ /home/u/.aws/config\n@@"; !strings.HasPrefix(got, header) {
+		t.Errorf("absolute path header mismatch, got:\n%s", got)
+	}
+}
+
+func TestUnifiedDiff_SeparateHunks(t *testing.T) {
+	var a []string
+	for i := 0; i < 20; i++ {
+		a = append(a, strings.Repeat("x", i+1))
+	}
+	b := append([]string(nil), a...)
+	b[1] = "changed"
+	b[18] = "changed"
+
+	got := unifiedDiff("f", a, b)
+	if n := strings.Count(got, "@@ -"); n != 2 {
+		t.Errorf("expected 2 hunks, got %d:\n%s", n, got)
+	}
+	if !strings.Contains(got, "@@ -1,5 +1,5 @@") || !strings.Contains(got, "@@ -16,5 +16,5 @@") {
+		t.Errorf("unexpected hunk headers:\n%s", got)
+	}
+}
+
+func TestUnifiedDiff_Identical(t *testing.T) {
+	a := []string{"[default]", "region = eu-west-1"}
+	if got := unifiedDiff("config", a, a); got != "" {
+		t.Errorf("expected empty diff, got %q", got)
+	}
+}
+
+func TestUnifiedDiff_NewFile(t *testing.T) {
+	got := unifiedDiff("config", nil, []string{"[default]", "region = eu-west-1"})
+	if !strings.Contains(got, "@@ -0,0 +1,2 @@") {
+		t.Errorf("unexpected diff for new file:\n%s", got)
+	}
+}
+
+func TestRedactDiff(t *testing.T) {
+	in := "--- a/credentials\n+++ b/credentials\n-aws_secret_access_key = old\n+aws_secret_access_key=new\n aws_access_key_id = AKIADEV\n+aws_session_token = tok"
+	want := "--- a/credentials\n+++ b/credentials\n-aws_secret_access_key = ****\n+aws_secret_access_key = ****\n aws_access_key_id = AKIADEV\n+aws_session_token = ****"
+	if got := redactDiff(in); got != want {
+		t.Errorf("redactDiff:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRun_DryRun(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, testCredentials)
+	defer cleanup()
+
+	out := captureStdout(t, func() {
+		if err := Run([]string{"awsctx", "p", "dev", "--dry-run"}); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	for _, want := range []string{"+++ " + awsConfigPath() + "\n", "+++ " + awsCredentialsPath() + "\n", "+region = us-west-2", "+[_awsctx_original_default]"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dry-run output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "dev-secret") || strings.Contains(out, "default-secret") {
+		t.Errorf("dry-run output leaks secrets:\n%s", out)
+	}
+
+	// Nothing written.
+	if data, _ := os.ReadFile(awsConfigPath()); string(data) != testConfig {
+		t.Errorf("config changed by dry run:\n%s", data)
+	}
+	if data, _ := os.ReadFile(awsCredentialsPath()); string(data) != testCredentials {
+		t.Errorf("credentials changed by dry run:\n%s", data)
+	}
+	if p := readState("profile"); p != "" {
+		t.Errorf("state changed by dry run: %s", p)
+	}
+
+	out = captureStdout(t, func() { Run([]string{"awsctx", "--dry-run", "r", "ap-south-1"}) })
+	if !strings.Contains(out, "+region = ap-south-1") {
+		t.Errorf("expected region diff, got:\n%s", out)
+	}
+	if r := readState("region"); r != "" {
+		t.Errorf("state changed by dry run: %s", r)
+	}
+}
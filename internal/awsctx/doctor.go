@@ -0,0 +1,446 @@
+package awsctx
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// severity ranks doctor findings.
+type severity int
+
+const (
+	severityOK severity = iota
+	severityInfo
+	severityWarning
+	severityError
+)
+
+func (s severity) String() string {
+	switch s {
+	case severityOK:
+		return "ok"
+	case severityInfo:
+		return "info"
+	case severityWarning:
+		return "warning"
+	default:
+		return "error"
+	}
+}
+
+// finding is the result of one doctor check.
+type finding struct {
+	Check    string
+	Severity severity
+	Message  string
+	Fix      string // suggestion for the user, if any
+
+	// repair applies the fix automatically; set only for safe repairs.
+	repair func() error
+}
+
+// doctorChecks run in order; each returns zero or more findings.
+var doctorChecks = []func() []finding{
+	checkAWSCLI,
+	checkMalformedLines,
+	checkDuplicateSections,
+	checkProfileDefault,
+	checkCredentialsPrefix,
+	checkSourceProfiles,
+	checkStaleBackup,
+	checkCredentialsPermissions,
+	checkShadowingEnv,
+	checkSSOTokens,
+}
+
+// checkAWSCLI reports whether the aws binary is available. awsctx only edits
+// the config files, so a missing CLI is informational.
+func checkAWSCLI() []finding {
+	path, err := exec.LookPath("aws")
+	if err != nil {
+		return []finding{{
+			Check:    "aws-cli",
+			Severity: severityInfo,
+			Message:  "aws CLI not found on PATH; awsctx works without it, SDKs and tools read the same config files",
+			Fix:      "install it from https://aws.amazon.com/cli/ if you need the CLI",
+		}}
+	}
+	return []finding{{Check: "aws-cli", Severity: severityOK, Message: "aws CLI found at " + path}}
+}
+
+// awsFiles returns the config and credentials files with their paths.
+func awsFiles() []*iniFile {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil
+	}
+	return []*iniFile{cfg.config, cfg.credentials}
+}
+
+func isSectionHeader(line string) bool {
+	_, ok := parseSectionHeader(line)
+	return ok
+}
+
+// checkMalformedLines finds lines that are neither blank, comments, section
+// headers, key = value pairs nor indented continuations of a nested value.
+func checkMalformedLines() []finding {
+	var findings []finding
+	for _, f := range awsFiles() {
+		for i, raw := range f.lines {
+			line := strings.TrimSpace(raw)
+			switch {
+			case line == "", strings.HasPrefix(line, "#"), strings.HasPrefix(line, ";"):
+			case isSectionHeader(line):
+			case strings.Contains(line, "="):
+			case raw != line && i > 0:
+				// indented continuation, e.g. nested s3 settings
+			default:
+				findings = append(findings, finding{
+					Check:    "malformed-line",
+					Severity: severityError,
+					Message:  fmt.Sprintf("%s:%d: cannot parse %q", f.path, i+1, redactLine(line)),
+					Fix:      "turn it into a key = value pair, a [section] header or a # comment",
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// checkDuplicateSections finds section headers that appear more than once in
+// a file; only the first one is read by awsctx.
+func checkDuplicateSections() []finding {
+	var findings []finding
+	for _, f := range awsFiles() {
+		seen := make(map[string]int)
+		for i, raw := range f.lines {
+			section, ok := parseSectionHeader(raw)
+			if !ok {
+				continue
+			}
+			if first, ok := seen[section]; ok {
+				findings = append(findings, finding{
+					Check:    "duplicate-section",
+					Severity: severityWarning,
+					Message:  fmt.Sprintf("%s:%d: [%s] already defined on line %d", f.path, i+1, section, first),
+					Fix:      "merge the two sections into one",
+				})
+				continue
+			}
+			seen[section] = i + 1
+		}
+	}
+	return findings
+}
+
+// checkProfileDefault flags [profile default] in the config file, which
+// conflicts with or shadows [default].
+func checkProfileDefault() []finding {
+	c, err := loadConfig()
+	if err != nil {
+		return nil
+	}
+	cfg := c.config
+	start, _, found := cfg.sectionRange("profile default")
+	// While switched, the [default] next to it is the one awsctx created.
+	if !found || hadNoDefault(cfg) {
+		return nil
+	}
+
+	f := finding{
+		Check:    "profile-default",
+		Severity: severityWarning,
+		Message:  fmt.Sprintf("%s:%d: [profile default] is used instead of [default]", cfg.path, start+1),
+		Fix:      "rename [profile default] to [default]",
+	}
+	if cfg.hasSection("default") {
+		f.Fix = "merge [profile default] into [default] and remove it"
+	} else {
+		f.repair = func() error {
+			c, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			ini := c.editConfig()
+			ini.lines[start] = "[default]"
+			return writeINI(ini)
+		}
+	}
+	return []finding{f}
+}
+
+// checkCredentialsPrefix flags [profile name] sections in the credentials
+// file, where the AWS CLI expects plain [name].
+func checkCredentialsPrefix() []finding {
+	c, err := loadConfig()
+	if err != nil {
+		return nil
+	}
+	creds := c.credentials
+	var findings []finding
+	for i, raw := range creds.lines {
+		section, _ := parseSectionHeader(raw)
+		name, ok := strings.CutPrefix(section, "profile ")
+		if !ok {
+			continue
+		}
+		line := i
+		f := finding{
+			Check:    "credentials-prefix",
+			Severity: severityWarning,
+			Message:  fmt.Sprintf("%s:%d: [profile %s] is not read from the credentials file", creds.path, i+1, name),
+			Fix:      fmt.Sprintf("rename it to [%s]", name),
+		}
+		if !creds.hasSection(name) {
+			// Reload so that earlier repairs of the same file are kept;
+			// renaming a header does not move any lines.
+			f.repair = func() error {
+				c, err := loadConfig()
+				if err != nil {
+					return err
+				}
+				ini := c.editCredentials()
+				ini.lines[line] = "[" + name + "]"
+				return writeINI(ini)
+			}
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// checkSourceProfiles flags source_profile settings pointing at profiles that
+// exist in neither file.
+func checkSourceProfiles() []finding {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil
+	}
+
+	var findings []finding
+	for _, e := range cfg.entries {
+		p := e.Name
+		src := cfg.config.getKeys(profileSection(p))["source_profile"]
+		if src == "" || cfg.hasProfile(src) || cfg.credentials.hasSection(src) {
+			continue
+		}
+		findings = append(findings, finding{
+			Check:    "source-profile",
+			Severity: severityError,
+			Message:  fmt.Sprintf("profile %s: source_profile %q does not exist", p, src),
+			Fix:      fmt.Sprintf("add a [profile %s] section or point source_profile at an existing profile", src),
+		})
+	}
+	return findings
+}
+
+// checkStaleBackup flags an _awsctx_original_default backup left behind
+// while awsctx believes the original default is active.
+func checkStaleBackup() []finding {
+	if p := readState("profile"); p != "" && p != "default" {
+		return nil
+	}
+	var findings []finding
+	for _, f := range awsFiles() {
+		if !f.hasSection("_awsctx_original_default") {
+			continue
+		}
+		// Restore only the file the backup was found in.
+		restore := switchProfileInConfig
+		if f.path == awsCredentialsPath() {
+			restore = switchProfileInCredentials
+		}
+		findings = append(findings, finding{
+			Check:    "stale-backup",
+			Severity: severityWarning,
+			Message:  fmt.Sprintf("%s: [_awsctx_original_default] exists but no profile is switched", f.path),
+			Fix:      "restore the original [default] with 'awsctx p default'",
+			repair:   func() error { return restore("default") },
+		})
+	}
+	return findings
+}
+
+// checkCredentialsPermissions flags a credentials file readable by others.
+func checkCredentialsPermissions() []finding {
+	path := awsCredentialsPath()
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	mode := info.Mode().Perm()
+	if mode&0o077 == 0 {
+		return []finding{{Check: "credentials-permissions", Severity: severityOK, Message: fmt.Sprintf("%s is %04o", path, mode)}}
+	}
+	return []finding{{
+		Check:    "credentials-permissions",
+		Severity: severityWarning,
+		Message:  fmt.Sprintf("%s is %04o, readable by other users", path, mode),
+		Fix:      "chmod 600 " + path,
+		repair: func() error {
+			if dryRun {
+				fmt.Printf("chmod 600 %s\n", path)
+				return nil
+			}
+			return os.Chmod(path, 0o600)
+		},
+	}}
+}
+
+// checkShadowingEnv flags environment variables overriding awsctx switches.
+func checkShadowingEnv() []finding {
+	var findings []finding
+	seen := make(map[string]bool)
+	for _, kind := range []string{"profile", "region"} {
+		for _, name := range shadowingEnv(kind) {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			findings = append(findings, finding{
+				Check:    "env-shadowing",
+				Severity: severityWarning,
+				Message:  fmt.Sprintf("%s is set and overrides the profile or region awsctx switches to", name),
+				Fix:      "unset " + name,
+			})
+		}
+	}
+	return findings
+}
+
+// ssoCacheDir is where the AWS CLI stores SSO access tokens.
+func ssoCacheDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".aws", "sso", "cache")
+}
+
+// checkSSOTokens flags expired SSO access tokens for every SSO session or
+// start URL used in the config file.
+func checkSSOTokens() []finding {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil
+	}
+
+	var findings []finding
+	checked := make(map[string]bool)
+	for _, e := range cfg.entries {
+		p := e.Name
+		keys := cfg.config.getKeys(profileSection(p))
+		cacheKey := ssoCacheKey(keys)
+		if cacheKey == "" || checked[cacheKey] {
+			continue
+		}
+		checked[cacheKey] = true
+
+		expiresAt, err := readSSOExpiry(cacheKey)
+		if err != nil {
+			findings = append(findings, finding{
+				Check:    "sso-token",
+				Severity: severityInfo,
+				Message:  fmt.Sprintf("no cached SSO token for %s", cacheKey),
+				Fix:      "aws sso login --profile " + p,
+			})
+			continue
+		}
+
+		expires, err := parseSSOExpiry(expiresAt)
+		if err != nil || time.Now().After(expires) {
+			findings = append(findings, finding{
+				Check:    "sso-token",
+				Severity: severityWarning,
+				Message:  fmt.Sprintf("SSO token for %s expired %s", cacheKey, expiresAt),
+				Fix:      "aws sso login --profile " + p,
+			})
+			continue
+		}
+		findings = append(findings, finding{
+			Check:    "sso-token",
+			Severity: severityOK,
+			Message:  fmt.Sprintf("SSO token for %s valid until %s", cacheKey, expires.Local().Format(time.RFC3339)),
+		})
+	}
+	return findings
+}
+
+// ssoCacheKey returns the key a profile's SSO token is cached under: the
+// session name, or the start URL for legacy profiles without sso_session.
+func ssoCacheKey(keys map[string]string) string {
+	if s := keys["sso_session"]; s != "" {
+		return s
+	}
+	return keys["sso_start_url"]
+}
+
+// ssoTokenPath returns the file the SSO token for cacheKey is cached in,
+// named after the SHA-1 of the key.
+func ssoTokenPath(cacheKey string) string {
+	sum := sha1.Sum([]byte(cacheKey))
+	return filepath.Join(ssoCacheDir(), hex.EncodeToString(sum[:])+".json")
+}
+
+// readSSOExpiry returns the raw expiresAt of the cached SSO token for cacheKey.
+func readSSOExpiry(cacheKey string) (string, error) {
+	data, err := os.ReadFile(ssoTokenPath(cacheKey))
+	if err != nil {
+		return "", err
+	}
+	var token struct {
+		ExpiresAt string `json:"expiresAt"`
+	}
+	json.Unmarshal(data, &token)
+	return token.ExpiresAt, nil
+}
+
+// parseSSOExpiry parses expiresAt from the SSO cache, which older CLI
+// versions wrote with a "UTC" suffix instead of "Z".
+func parseSSOExpiry(s string) (time.Time, error) {
+	return time.Parse(time.RFC3339, strings.Replace(s, "UTC", "Z", 1))
+}
+
+// doctor runs all checks and prints their findings. With fix, safe repairs
+// are applied. It fails if any unrepaired finding is a warning or an error.
+func doctor(fix bool) error {
+	problems := 0
+	for _, check := range doctorChecks {
+		for _, f := range check() {
+			printFinding(f)
+			if f.Severity < severityWarning {
+				continue
+			}
+			if fix && f.repair != nil {
+				if err := f.repair(); err != nil {
+					fmt.Printf("          fix failed: %v\n", err)
+				} else if !dryRun {
+					fmt.Printf("          fixed\n")
+					continue
+				}
+			}
+			problems++
+		}
+	}
+	if problems > 0 {
+		return fmt.Errorf("doctor found %d problem(s)", problems)
+	}
+	return nil
+}
+
+func printFinding(f finding) {
+	fmt.Printf("%-9s %s: %s\n", "["+f.Severity.String()+"]", f.Check, redactString(f.Message))
+	if f.Fix == "" || f.Severity == severityOK {
+		return
+	}
+	auto := ""
+	if f.repair != nil {
+		auto = " (--fix)"
+	}
+	fmt.Printf("          fix: %s%s\n", f.Fix, auto)
+}
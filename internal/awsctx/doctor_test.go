@@ -0,0 +1,227 @@
+package awsctx
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCheckAWSCLI(t *testing.T) {
+	t.Setenv("PATH", "")
+	f := checkAWSCLI()
+	if len(f) != 1 || f[0].Severity != severityInfo {
+		t.Errorf("expected an info finding without aws CLI, got %+v", f)
+	}
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "aws"), []byte("#!/bin/sh\n"), 0o755)
+	t.Setenv("PATH", dir)
+	f = checkAWSCLI()
+	if len(f) != 1 || f[0].Severity != severityOK {
+		t.Errorf("expected an ok finding with aws CLI, got %+v", f)
+	}
+}
+
+const testBrokenConfig = `[default]
+region = eu-west-1
+this is not valid
+
+[profile dev]
+region = us-west-2
+s3 =
+  max_concurrent_requests = 20
+
+[profile dev]
+output = json
+
+[profile default]
+output = text
+
+[profile ro]
+role_arn = arn:aws:iam::111111111111:role/ReadOnly
+source_profile = missing
+`
+
+const testBrokenCredentials = `[default]
+aws_access_key_id = AKIADEFAULT
+aws_secret_access_key = default-secret
+
+[profile ci]
+aws_access_key_id = AKIACI
+aws_secret_access_key = ci-secret
+`
+
+// findingsFor returns the findings of check with the given name.
+func findingsFor(check func() []finding, name string) []finding {
+	var out []finding
+	for _, f := range check() {
+		if f.Check == name {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func TestDoctorChecks(t *testing.T) {
+	cleanup := setupTestAWS(t, testBrokenConfig, testBrokenCredentials)
+	defer cleanup()
+
+	tests := []struct {
+		check    func() []finding
+		name     string
+		count    int
+		severity severity
+	}{
+		{checkMalformedLines, "malformed-line", 1, severityError},
+		{checkDuplicateSections, "duplicate-section", 1, severityWarning},
+		{checkProfileDefault, "profile-default", 1, severityWarning},
+		{checkCredentialsPrefix, "credentials-prefix", 1, severityWarning},
+		{checkSourceProfiles, "source-profile", 1, severityError},
+		{checkCredentialsPermissions, "credentials-permissions", 1, severityWarning},
+	}
+	for _, tt := range tests {
+		got := findingsFor(tt.check, tt.name)
+		if len(got) != tt.count {
+			t.Errorf("%s: expected %d finding(s), got %+v", tt.name, tt.count, got)
+			continue
+		}
+		if got[0].Severity != tt.severity {
+			t.Errorf("%s: expected severity %s, got %s", tt.name, tt.severity, got[0].Severity)
+		}
+		if got[0].Fix == "" {
+			t.Errorf("%s: expected a fix suggestion", tt.name)
+		}
+	}
+}
+
+func TestDoctorChecks_Clean(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	for _, check := range doctorChecks {
+		for _, f := range check() {
+			if f.Severity >= severityWarning {
+				t.Errorf("unexpected finding on a clean config: %+v", f)
+			}
+		}
+	}
+}
+
+func TestCheckStaleBackup(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, testCredentials)
+	defer cleanup()
+
+	Run([]string{"awsctx", "p", "dev"})
+	if f := checkStaleBackup(); len(f) != 0 {
+		t.Errorf("backup of an active switch is not stale, got %+v", f)
+	}
+
+	// State lost while dev is still copied into [default].
+	os.Remove(filepath.Join(cacheDir(), "current_profile"))
+	if f := checkStaleBackup(); len(f) != 2 {
+		t.Fatalf("expected stale backups in both files, got %+v", f)
+	}
+}
+
+func TestCheckStaleBackup_RepairOnlyItsFile(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, testCredentials)
+	defer cleanup()
+
+	// Only the credentials file still holds a switch.
+	Run([]string{"awsctx", "p", "dev"})
+	os.Remove(filepath.Join(cacheDir(), "current_profile"))
+	config := "[default]\nregion = eu-west-1\n\n\n[profile dev]\nregion=us-west-2\n"
+	os.WriteFile(awsConfigPath(), []byte(config), 0o644)
+
+	findings := checkStaleBackup()
+	if len(findings) != 1 {
+		t.Fatalf("expected a stale backup in the credentials file only, got %+v", findings)
+	}
+	if err := findings[0].repair(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(awsConfigPath()); string(data) != config {
+		t.Errorf("config file must be left alone, got:\n%s", data)
+	}
+	creds, _ := loadINI(awsCredentialsPath())
+	if creds.hasSection("_awsctx_original_default") || creds.getKeys("default")["aws_access_key_id"] != "AKIADEFAULT" {
+		t.Errorf("expected the credentials [default] restored:\n%v", creds.lines)
+	}
+}
+
+func TestCheckShadowingEnv(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	t.Setenv("AWS_PROFILE", "dev")
+	t.Setenv("AWS_REGION", "us-east-1")
+	f := checkShadowingEnv()
+	if len(f) != 2 {
+		t.Errorf("expected AWS_PROFILE and AWS_REGION findings, got %+v", f)
+	}
+}
+
+func TestCheckSSOTokens(t *testing.T) {
+	cleanup := setupTestAWS(t, `[profile valid]
+sso_session = corp
+sso_account_id = 111111111111
+
+[profile expired]
+sso_start_url = https://old.awsapps.com/start
+
+[profile missing]
+sso_start_url = https://new.awsapps.com/start
+`, "")
+	defer cleanup()
+	t.Setenv("HOME", t.TempDir())
+
+	os.MkdirAll(ssoCacheDir(), 0o755)
+	writeToken := func(key, expires string) {
+		sum := sha1.Sum([]byte(key))
+		os.WriteFile(filepath.Join(ssoCacheDir(), hex.EncodeToString(sum[:])+".json"),
+			[]byte(`{"startUrl": "x", "expiresAt": "`+expires+`"}`), 0o600)
+	}
+	writeToken("corp", time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	writeToken("https://old.awsapps.com/start", "2020-01-01T00:00:00UTC")
+
+	got := map[severity]int{}
+	for _, f := range checkSSOTokens() {
+		got[f.Severity]++
+	}
+	want := map[severity]int{severityOK: 1, severityWarning: 1, severityInfo: 1}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestDoctorFix(t *testing.T) {
+	cleanup := setupTestAWS(t, `[profile default]
+region = eu-west-1
+`, testBrokenCredentials)
+	defer cleanup()
+
+	if err := doctor(true); err != nil {
+		t.Fatalf("expected all problems to be fixed, got %v", err)
+	}
+
+	cfg, _ := loadINI(awsConfigPath())
+	if !cfg.hasSection("default") || cfg.hasSection("profile default") {
+		t.Errorf("expected [profile default] renamed to [default]:\n%v", cfg.lines)
+	}
+	creds, _ := loadINI(awsCredentialsPath())
+	if !creds.hasSection("ci") || creds.hasSection("profile ci") {
+		t.Errorf("expected [profile ci] renamed to [ci]:\n%v", creds.lines)
+	}
+	info, _ := os.Stat(awsCredentialsPath())
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("expected credentials mode 0600, got %04o", info.Mode().Perm())
+	}
+
+	if err := doctor(false); err != nil {
+		t.Errorf("expected no problems after fixing, got %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package awsctx
+
+import (
+	"fmt"
+	"os"
+)
+
+// precedenceEntry is one source in the AWS CLI's lookup chain for a setting.
+// An empty Value means the source doesn't provide the setting.
+type precedenceEntry struct {
+	Source string
+	Value  string
+}
+
+// winner returns the index of the first entry that provides a value, or -1.
+func winner(chain []precedenceEntry) int {
+	for i, e := range chain {
+		if e.Value != "" {
+			return i
+		}
+	}
+	return -1
+}
+
+// effectiveProfile returns the profile the AWS CLI and SDKs will use.
+// awsctx switches by rewriting [default], so without AWS_PROFILE that's "default".
+func effectiveProfile() string {
+	if p := os.Getenv("AWS_PROFILE"); p != "" {
+		return p
+	}
+	return "default"
+}
+
+// profileChain returns the sources that decide which profile is used.
+func profileChain() []precedenceEntry {
+	return []precedenceEntry{
+		{"env AWS_PROFILE", os.Getenv("AWS_PROFILE")},
+		{"config [default] (awsctx)", copiedProfile()},
+	}
+}
+
+// regionChain returns the sources that decide which region is used.
+func regionChain() []precedenceEntry {
+	profile := effectiveProfile()
+	return []precedenceEntry{
+		{"env AWS_REGION", os.Getenv("AWS_REGION")},
+		{"env AWS_DEFAULT_REGION", os.Getenv("AWS_DEFAULT_REGION")},
+		{"config [" + profileSection(profile) + "]", getProfileRegion(profile)},
+	}
+}
+
+// credentialsChain returns the sources that decide which credentials are used.
+// Values describe the source; secrets are never included.
+func credentialsChain() []precedenceEntry {
+	var envKeys string
+	if os.Getenv("AWS_ACCESS_KEY_ID") != "" {
+		envKeys = "static keys"
+	}
+
+	profile := effectiveProfile()
+	section := profileSection(profile)
+	var fileKeys, configCreds string
+	if cfg, err := loadConfig(); err == nil {
+		if cfg.credentials.getKeys(profile)["aws_access_key_id"] != "" {
+			fileKeys = "static keys"
+		}
+		configCreds = credentialKind(cfg.config.getKeys(section))
+	}
+
+	return []precedenceEntry{
+		{"env AWS_ACCESS_KEY_ID", envKeys},
+		{"credentials [" + profile + "]", fileKeys},
+		{"config [" + section + "]", configCreds},
+	}
+}
+
+// credentialKind describes how a config profile obtains credentials, or
+// returns "" if it defines none itself.
+func credentialKind(keys map[string]string) string {
+	switch {
+	case keys["aws_access_key_id"] != "":
+		return "static keys"
+	case keys["sso_session"] != "" || keys["sso_start_url"] != "":
+		return "sso"
+	case keys["role_arn"] != "":
+		return "assume role"
+	case keys["credential_process"] != "":
+		return "credential_process"
+	}
+	return ""
+}
+
+// shadowingVars maps a switch kind to the environment variables that override
+// what awsctx writes to the config files.
+var shadowingVars = map[string][]string{
+	"profile": {"AWS_PROFILE", "AWS_ACCESS_KEY_ID"},
+	"region":  {"AWS_REGION", "AWS_DEFAULT_REGION", "AWS_PROFILE"},
+}
+
+// shadowingEnv returns the environment variables currently overriding a
+// switch of the given kind ("profile" or "region").
+func shadowingEnv(kind string) []string {
+	var set []string
+	for _, name := range shadowingVars[kind] {
+		if os.Getenv(name) != "" {
+			set = append(set, name)
+		}
+	}
+	return set
+}
+
+// warnShadowing prints a warning for each environment variable that will keep
+// overriding a switch of the given kind.
+func warnShadowing(kind string) {
+	for _, name := range shadowingEnv(kind) {
+		switch name {
+		case "AWS_ACCESS_KEY_ID":
+			fmt.Fprintf(os.Stderr, "warning: AWS_ACCESS_KEY_ID is set; its credentials override the switched profile's (unset AWS_ACCESS_KEY_ID AWS_SECRET_ACCESS_KEY AWS_SESSION_TOKEN)\n")
+		case "AWS_PROFILE":
+			fmt.Fprintf(os.Stderr, "warning: AWS_PROFILE=%s is set and overrides the switched %s (unset AWS_PROFILE)\n", os.Getenv(name), kind)
+		default:
+			fmt.Fprintf(os.Stderr, "warning: %s=%s is set and overrides the switched region (unset %s)\n", name, os.Getenv(name), name)
+		}
+	}
+}
+
+// explain prints the full precedence chain for profile, region and
+// credentials, marking the source that wins.
+func explain() error {
+	printChain("profile", profileChain())
+	printChain("region", regionChain())
+	printChain("credentials", credentialsChain())
+	return nil
+}
+
+func printChain(title string, chain []precedenceEntry) {
+	fmt.Printf("%s:\n", title)
+	won := winner(chain)
+	for i, e := range chain {
+		value := e.Value
+		if value == "" {
+			value = "(unset)"
+		}
+		marker := " "
+		if i == won {
+			marker = "*"
+		}
+		fmt.Printf("  %s %-28s %s\n", marker, e.Source, value)
+	}
+}
@@ -0,0 +1,103 @@
+package awsctx
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestShadowingEnv(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	if vars := shadowingEnv("region"); len(vars) != 0 {
+		t.Errorf("expected no shadowing vars, got %v", vars)
+	}
+
+	os.Setenv("AWS_REGION", "us-east-1")
+	os.Setenv("AWS_PROFILE", "dev")
+	if vars := shadowingEnv("region"); !reflect.DeepEqual(vars, []string{"AWS_REGION", "AWS_PROFILE"}) {
+		t.Errorf("unexpected region shadowing vars: %v", vars)
+	}
+	if vars := shadowingEnv("profile"); !reflect.DeepEqual(vars, []string{"AWS_PROFILE"}) {
+		t.Errorf("unexpected profile shadowing vars: %v", vars)
+	}
+}
+
+func TestRegionChain(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	chain := regionChain()
+	if w := winner(chain); w != 2 || chain[w].Value != "eu-west-1" {
+		t.Errorf("expected config [default] to win with eu-west-1, got %d %v", w, chain)
+	}
+
+	os.Setenv("AWS_DEFAULT_REGION", "ap-south-1")
+	chain = regionChain()
+	if w := winner(chain); w != 1 {
+		t.Errorf("expected AWS_DEFAULT_REGION to win, got %d %v", w, chain)
+	}
+
+	os.Setenv("AWS_REGION", "us-east-1")
+	chain = regionChain()
+	if w := winner(chain); w != 0 {
+		t.Errorf("expected AWS_REGION to win, got %d %v", w, chain)
+	}
+}
+
+func TestRegionChain_ProfileFromEnv(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	os.Setenv("AWS_PROFILE", "dev")
+	chain := regionChain()
+	if chain[2].Source != "config [profile dev]" || chain[2].Value != "us-west-2" {
+		t.Errorf("expected dev's region from config, got %v", chain[2])
+	}
+}
+
+func TestCurrentRegion_MatchesRegionChain(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	agree := func(want string) {
+		t.Helper()
+		chain := regionChain()
+		if r := currentRegion(); r != want || chain[winner(chain)].Value != want {
+			t.Errorf("currentRegion %s, explain %v, want %s", r, chain, want)
+		}
+	}
+
+	Run([]string{"awsctx", "r", "eu-west-3"})
+	agree("eu-west-3")
+	// The profile switch replaces the region in [default].
+	Run([]string{"awsctx", "p", "dev"})
+	agree("us-west-2")
+
+	t.Setenv("AWS_PROFILE", "staging")
+	agree("eu-west-1")
+}
+
+func TestCredentialsChain(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, testCredentials)
+	defer cleanup()
+
+	chain := credentialsChain()
+	if w := winner(chain); w != 1 {
+		t.Errorf("expected credentials file to win, got %d %v", w, chain)
+	}
+
+	os.Setenv("AWS_ACCESS_KEY_ID", "AKIAENV")
+	defer os.Unsetenv("AWS_ACCESS_KEY_ID")
+	chain = credentialsChain()
+	if w := winner(chain); w != 0 {
+		t.Errorf("expected env keys to win, got %d %v", w, chain)
+	}
+}
+
+func TestWinner_None(t *testing.T) {
+	if w := winner([]precedenceEntry{{"a", ""}, {"b", ""}}); w != -1 {
+		t.Errorf("expected -1, got %d", w)
+	}
+}
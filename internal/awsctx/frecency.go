@@ -0,0 +1,92 @@
+package awsctx
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// sortOrder is set from the global --sort flag. Empty means the default for
+// the context, see sortFor.
+var sortOrder string
+
+var sortOrders = []string{"name", "frecency", "file"}
+
+// sortFor returns the order of a listing: --sort if given, otherwise
+// frecency for picker input and completions and file order for listings.
+func sortFor(picker bool) string {
+	switch {
+	case sortOrder != "":
+		return sortOrder
+	case picker:
+		return "frecency"
+	}
+	return "file"
+}
+
+// frecencyWeight is the score of one use, decaying with its age.
+func frecencyWeight(age time.Duration) float64 {
+	const day = 24 * time.Hour
+	switch {
+	case age < 4*day:
+		return 100
+	case age < 14*day:
+		return 70
+	case age < 31*day:
+		return 50
+	case age < 90*day:
+		return 30
+	}
+	return 10
+}
+
+// frecencyScores sums the weights of every switch in the history that left
+// a profile or region (kind) in use, combining frequency and recency.
+// Switches to a profile without a region count for no region.
+func frecencyScores(kind string) map[string]float64 {
+	scores := make(map[string]float64)
+	now := time.Now()
+	for _, h := range readHistory() {
+		name := h.Profile
+		if kind == "region" {
+			name = h.Region
+		}
+		if name == "" {
+			continue
+		}
+		scores[name] += frecencyWeight(now.Sub(h.Time))
+	}
+	return scores
+}
+
+// sortByOrder sorts items by the name of each in the given order; "file"
+// keeps them as they are. Sorting is stable, so unused entries keep their
+// file order under frecency.
+func sortByOrder[T any](items []T, name func(T) string, kind, order string) {
+	switch order {
+	case "name":
+		slices.SortStableFunc(items, func(a, b T) int { return strings.Compare(name(a), name(b)) })
+	case "frecency":
+		scores := frecencyScores(kind)
+		slices.SortStableFunc(items, func(a, b T) int { return cmp.Compare(scores[name(b)], scores[name(a)]) })
+	}
+}
+
+// sortedProfiles returns entries in the given order, pinned ones first.
+func sortedProfiles(entries []profileEntry, order string) []profileEntry {
+	entries = slices.Clone(entries)
+	name := func(e profileEntry) string { return e.Name }
+	sortByOrder(entries, name, "profile", order)
+	pinnedFirst(entries, name, pinnedSet("profile"))
+	return entries
+}
+
+// sortedRegions returns awsRegions in the given order, pinned ones first.
+func sortedRegions(order string) []string {
+	regions := slices.Clone(awsRegions)
+	name := func(r string) string { return r }
+	sortByOrder(regions, name, "region", order)
+	pinnedFirst(regions, name, pinnedSet("region"))
+	return regions
+}
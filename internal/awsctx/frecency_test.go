@@ -0,0 +1,120 @@
+package awsctx
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFrecencyWeight(t *testing.T) {
+	day := 24 * time.Hour
+	ages := []time.Duration{time.Hour, 10 * day, 20 * day, 60 * day, 365 * day}
+	prev := frecencyWeight(0)
+	for _, age := range ages[1:] {
+		w := frecencyWeight(age)
+		if w >= prev {
+			t.Errorf("weight at %v = %v, want less than %v", age, w, prev)
+		}
+		prev = w
+	}
+}
+
+func TestFrecencyScores(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	now := time.Now().Unix()
+	old := time.Now().Add(-200 * 24 * time.Hour).Unix()
+	writeHistory(t, fmt.Sprintf(`%d staging eu-west-1
+%d staging eu-west-1
+%d staging eu-west-1
+%d dev eu-west-1
+`, old, old, old, now))
+
+	scores := frecencyScores("profile")
+	if scores["dev"] <= scores["staging"] {
+		t.Errorf("one recent use should outrank three old ones: %v", scores)
+	}
+	if s := frecencyScores("region")["eu-west-1"]; s != scores["dev"]+scores["staging"] {
+		t.Errorf("region score = %v, want the sum of all uses", s)
+	}
+}
+
+func TestSortedProfiles(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	writeHistory(t, fmt.Sprintf("%d staging eu-west-1\n", time.Now().Unix()))
+	entries, _ := getProfileEntries()
+
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		{"file", []string{"default", "dev", "staging"}},
+		{"name", []string{"default", "dev", "staging"}},
+		{"frecency", []string{"staging", "default", "dev"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, e := range sortedProfiles(entries, tt.order) {
+			got = append(got, e.Name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s order = %v, want %v", tt.order, got, tt.want)
+		}
+	}
+
+	if got := sortedRegions("name"); got[0] != "af-south-1" {
+		t.Errorf("first region by name = %s", got[0])
+	}
+	if got := sortedRegions("frecency"); got[0] != "eu-west-1" {
+		t.Errorf("first region by frecency = %s", got[0])
+	}
+}
+
+func TestSortFor(t *testing.T) {
+	defer func() { sortOrder = "" }()
+
+	if sortFor(false) != "file" || sortFor(true) != "frecency" {
+		t.Errorf("defaults = %s, %s", sortFor(false), sortFor(true))
+	}
+	sortOrder = "name"
+	if sortFor(false) != "name" || sortFor(true) != "name" {
+		t.Errorf("--sort should apply to both, got %s, %s", sortFor(false), sortFor(true))
+	}
+}
+
+func TestRun_Sort(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+	defer func() { sortOrder = "" }()
+
+	writeHistory(t, fmt.Sprintf("%d staging eu-west-1\n", time.Now().Unix()))
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"awsctx", "p", "--color=never"}, "default\ndev\nstaging\n"},
+		{[]string{"awsctx", "p", "--color=never", "--sort=frecency"}, "staging\ndefault\ndev\n"},
+		{[]string{"awsctx", "--fzf-list", "profile", "--color=never"}, "staging\tstaging\ndefault\tdefault\ndev\tdev\n"},
+		{[]string{"awsctx", "--fzf-list", "profile", "--color=never", "--sort", "file"}, "default\tdefault\ndev\tdev\nstaging\tstaging\n"},
+	}
+	for _, tt := range tests {
+		out := captureStdout(t, func() {
+			if err := Run(tt.args); err != nil {
+				t.Errorf("%v: %v", tt.args, err)
+			}
+		})
+		if out != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", strings.Join(tt.args[1:], " "), out, tt.want)
+		}
+	}
+
+	if err := Run([]string{"awsctx", "p", "--sort=size"}); ExitCode(err) != exitUsage {
+		t.Errorf("expected a usage error for an unknown order, got %v", err)
+	}
+}
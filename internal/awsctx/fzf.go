@@ -10,74 +10,184 @@ import (
 	"golang.org/x/term"
 )
 
+// fzfListing is set while printing the --fzf-list input. Its lines start
+// with the name, or the profile and region of a pair, in tab-separated
+// fields that fzf hides (see fzfArgs), so names may contain spaces.
+var fzfListing bool
+
+// listingLine returns the listing line showing label for name, with name
+// as hidden fields in fzf input.
+func listingLine(name, label string) string {
+	if fzfListing {
+		return name + "\t" + label
+	}
+	return label
+}
+
 func hasFzf() bool {
 	_, err := exec.LookPath("fzf")
 	return err == nil
 }
 
+// isInteractive reports whether a picker may be shown: the listing would go
+// to a terminal rather than a pipe or command substitution.
 func isInteractive() bool {
-	return term.IsTerminal(int(os.Stderr.Fd()))
+	return term.IsTerminal(int(os.Stdout.Fd())) && term.IsTerminal(int(os.Stderr.Fd()))
 }
 
 // runFzf launches fzf for interactive selection.
-// subcommand is "profile" or "region".
-// Returns the user's choice or empty string if cancelled.
-func runFzf(subcommand string) (string, error) {
+// subcommand is "profile", "region" or "pick"; keys are passed to --expect
+// and a non-empty query prefilters the list. Returns the key the choice was
+// made with (empty for Enter) and the user's choice, or an empty choice if
+// cancelled.
+func runFzf(subcommand string, keys []string, query string) (key, choice string, err error) {
 	selfCmd, _ := os.Executable()
 	if selfCmd == "" {
 		selfCmd = os.Args[0]
 	}
+	self := shellQuote(selfCmd)
 
-	cmd := exec.Command("fzf", "--ansi", "--no-preview")
+	args, err := fzfArgs(self, subcommand, keys, query)
+	if err != nil {
+		return "", "", err
+	}
+	cmd := exec.Command("fzf", args...)
 	var out bytes.Buffer
 	cmd.Stdin = os.Stdin
 	cmd.Stderr = os.Stderr
 	cmd.Stdout = &out
 	cmd.Env = append(os.Environ(),
-		fmt.Sprintf("FZF_DEFAULT_COMMAND=%s --fzf-list %s", selfCmd, subcommand),
-		"_AWSCTX_FORCE_COLOR=1",
+		fmt.Sprintf("FZF_DEFAULT_COMMAND=%s --color=always --fzf-list %s", self, subcommand),
 	)
 
 	if err := cmd.Run(); err != nil {
 		if _, ok := err.(*exec.ExitError); ok {
 			// fzf was cancelled (e.g. Esc/Ctrl-C)
-			return "", nil
+			return "", "", nil
 		}
-		return "", err
+		return "", "", err
+	}
+	key, choice = parseFzfOutput(out.String(), subcommand == "pick", len(keys) > 0)
+	return key, choice, nil
+}
+
+// fzfArgs returns the fzf command line. fzf reads FZF_DEFAULT_OPTS first;
+// the awsctx defaults override it and are in turn overridden by
+// AWSCTX_FZF_OPTS, while the keys and query of this invocation come last.
+func fzfArgs(self, subcommand string, keys []string, query string) ([]string, error) {
+	// Pairs from pick are previewed by their profile, and their label
+	// follows two hidden fields rather than one.
+	previewKind, withNth := subcommand, "2.."
+	if subcommand == "pick" {
+		previewKind, withNth = "profile", "3.."
 	}
 
-	return strings.TrimSpace(out.String()), nil
+	args := []string{"--ansi", "--height", "50%",
+		"--delimiter", "\t", "--with-nth", withNth,
+		"--preview", fmt.Sprintf("%s --color=always --fzf-preview %s {1}", self, previewKind),
+		"--preview-window", "right,50%,wrap",
+	}
+	opts, err := splitWords(os.Getenv("AWSCTX_FZF_OPTS"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid AWSCTX_FZF_OPTS: %w", err)
+	}
+	args = append(args, opts...)
+	if len(keys) > 0 {
+		args = append(args, "--expect", strings.Join(keys, ","), "--header", actionHeader(keys))
+	}
+	if query != "" {
+		args = append(args, "--query", query, "--select-1")
+	}
+	return args, nil
+}
+
+// splitWords splits s into words the way a POSIX shell would, honoring
+// single and double quotes and backslash escapes, but without expansions.
+func splitWords(s string) ([]string, error) {
+	var (
+		words   []string
+		word    strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+	for _, r := range s {
+		switch {
+		case escaped:
+			if quote == '"' && !strings.ContainsRune(`"\$`+"`", r) {
+				word.WriteRune('\\')
+			}
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	switch {
+	case escaped:
+		return nil, fmt.Errorf("trailing backslash in %q", s)
+	case quote != 0:
+		return nil, fmt.Errorf("unterminated %c quote in %q", quote, s)
+	case inWord:
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// parseFzfOutput returns the key and the name of the selected line from
+// fzf's output. With --expect (expect), the first line is the key pressed,
+// empty for Enter. The name is the first tab-separated field (see
+// listingLine); pick lines (pair) have a profile and a region.
+func parseFzfOutput(out string, pair, expect bool) (key, choice string) {
+	if expect {
+		key, out, _ = strings.Cut(out, "\n")
+	}
+	line, _, _ := strings.Cut(out, "\n")
+	if line == "" {
+		return key, ""
+	}
+	fields := strings.Split(line, "\t")
+	if pair && len(fields) >= 2 {
+		return key, fields[0] + " " + fields[1]
+	}
+	return key, fields[0]
+}
+
+// shellQuote quotes s for the shell fzf runs its commands with.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
 }
 
 // fzfList prints items to stdout for fzf consumption.
 func fzfList(subcommand string) error {
+	fzfListing = true
+	defer func() { fzfListing = false }()
+
 	switch subcommand {
 	case "profile":
-		profiles, err := getProfiles()
-		if err != nil {
-			return err
-		}
-		cur := currentProfile()
-		forceColor := os.Getenv("_AWSCTX_FORCE_COLOR") == "1"
-		for _, p := range profiles {
-			if forceColor && p == cur {
-				fmt.Printf("\033[33m\033[40m%s\033[0m\n", p)
-			} else {
-				fmt.Println(p)
-			}
-		}
+		return listProfiles(sortFor(true), false)
 	case "region":
-		cur := currentRegion()
-		forceColor := os.Getenv("_AWSCTX_FORCE_COLOR") == "1"
-		for _, r := range awsRegions {
-			if forceColor && r == cur {
-				fmt.Printf("\033[33m\033[40m%s\033[0m\n", r)
-			} else {
-				fmt.Println(r)
-			}
-		}
+		return listRegions(currentRegion(), sortFor(true), false)
+	case "pick":
+		return listContexts(sortFor(true))
 	default:
 		return fmt.Errorf("unknown subcommand for --fzf-list: %s", subcommand)
 	}
-	return nil
 }
@@ -0,0 +1,88 @@
+package awsctx
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"  --height=40%   --reverse ", []string{"--height=40%", "--reverse"}},
+		{`--bind 'ctrl-a:select-all' --prompt "aws> "`, []string{"--bind", "ctrl-a:select-all", "--prompt", "aws> "}},
+		{`--color=fg:\#fff a\ b "x\"y\z" ''`, []string{"--color=fg:#fff", "a b", `x"y\z`, ""}},
+	}
+	for _, tt := range tests {
+		got, err := splitWords(tt.in)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitWords(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{`--prompt 'aws`, `--prompt "aws`, `a\`} {
+		if _, err := splitWords(in); err == nil {
+			t.Errorf("splitWords(%q): expected an error", in)
+		}
+	}
+}
+
+func TestFzfArgs(t *testing.T) {
+	t.Setenv("AWSCTX_FZF_OPTS", "--height=100% --prompt 'aws> '")
+
+	args, err := fzfArgs("awsctx", "profile", []string{"ctrl-e"}, "pro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(args, " ")
+	for _, want := range []string{
+		"--ansi --height 50% --delimiter \t --with-nth 2.. --preview awsctx --color=always --fzf-preview profile {1}",
+		"wrap --height=100% --prompt aws>  --expect ctrl-e --header enter: switch, ctrl-e: shell --query pro --select-1",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("fzf args missing %q:\n%s", want, got)
+		}
+	}
+
+	args, _ = fzfArgs("awsctx", "pick", nil, "")
+	got = strings.Join(args, " ")
+	if !strings.Contains(got, "--with-nth 3.. --preview awsctx --color=always --fzf-preview profile") || strings.Contains(got, "--query") || strings.Contains(got, "--expect") {
+		t.Errorf("unexpected fzf args for pick without a query:\n%s", got)
+	}
+
+	t.Setenv("AWSCTX_FZF_OPTS", "--prompt 'aws")
+	if _, err := fzfArgs("awsctx", "profile", nil, ""); err == nil || !strings.Contains(err.Error(), "AWSCTX_FZF_OPTS") {
+		t.Errorf("expected an AWSCTX_FZF_OPTS error, got %v", err)
+	}
+}
+
+func TestFzfList_NameWithSpaces(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig+"\n[profile my team]\nregion = eu-west-1\n", "")
+	defer cleanup()
+	writeHistory(t, "1700000000 my team eu-west-1\n")
+
+	for _, tt := range []struct {
+		kind, want string
+	}{
+		{"profile", "my team"},
+		{"pick", "my team eu-west-1"},
+	} {
+		out := captureStdout(t, func() {
+			if err := Run([]string{"awsctx", "--fzf-list", tt.kind, "--color=never"}); err != nil {
+				t.Fatal(err)
+			}
+		})
+		var found bool
+		for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+			if _, choice := parseFzfOutput(line+"\n", tt.kind == "pick", false); choice == tt.want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("--fzf-list %s: no line parses as %q:\n%s", tt.kind, tt.want, out)
+		}
+	}
+}
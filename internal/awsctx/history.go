@@ -0,0 +1,98 @@
+package awsctx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// historyLimit caps the number of switches kept in the history file.
+const historyLimit = 500
+
+// historyNoRegion stands for the region of a profile without one in the
+// history file.
+const historyNoRegion = "-"
+
+// historyEntry is the profile and region written to [default] by a switch;
+// Region is empty if the profile has none.
+type historyEntry struct {
+	Time    time.Time
+	Profile string
+	Region  string
+}
+
+func historyPath() string {
+	return filepath.Join(cacheDir(), "history")
+}
+
+// readHistory returns the recorded switches, oldest first. The file has one
+// "unix-time profile region" line per switch; malformed lines are skipped.
+// Older versions wrote "(none)" for a missing region. Profile names may
+// contain spaces, so the region is the last field.
+func readHistory() []historyEntry {
+	data, err := os.ReadFile(historyPath())
+	if err != nil {
+		return nil
+	}
+	var entries []historyEntry
+	for _, line := range strings.Split(string(data), "\n") {
+		stamp, rest, _ := strings.Cut(strings.TrimSpace(line), " ")
+		profile, region := splitPair(rest)
+		if profile == "" || region == "" {
+			continue
+		}
+		sec, err := strconv.ParseInt(stamp, 10, 64)
+		if err != nil {
+			continue
+		}
+		if region == historyNoRegion || region == "(none)" {
+			region = ""
+		}
+		entries = append(entries, historyEntry{Time: time.Unix(sec, 0), Profile: profile, Region: region})
+	}
+	return entries
+}
+
+// recordHistory appends a switch to the history, dropping the oldest
+// entries beyond historyLimit. profile and region are the ones written to
+// [default], not the environment's.
+func recordHistory(profile, region string) {
+	if region == "(none)" {
+		region = ""
+	}
+	entries := append(readHistory(), historyEntry{Time: time.Now(), Profile: profile, Region: region})
+	if len(entries) > historyLimit {
+		entries = entries[len(entries)-historyLimit:]
+	}
+
+	var b strings.Builder
+	for _, e := range entries {
+		region := e.Region
+		if region == "" {
+			region = historyNoRegion
+		}
+		fmt.Fprintf(&b, "%d %s %s\n", e.Time.Unix(), e.Profile, region)
+	}
+	os.MkdirAll(cacheDir(), 0o755)
+	os.WriteFile(historyPath(), []byte(b.String()), 0o644)
+}
+
+// recentPairs returns up to limit distinct profile and region pairs from
+// the history, most recent first.
+func recentPairs(limit int) []historyEntry {
+	entries := readHistory()
+	seen := make(map[[2]string]bool)
+	var pairs []historyEntry
+	for i := len(entries) - 1; i >= 0 && len(pairs) < limit; i-- {
+		key := [2]string{entries[i].Profile, entries[i].Region}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		pairs = append(pairs, entries[i])
+	}
+	return pairs
+}
@@ -0,0 +1,119 @@
+package awsctx
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRecordHistory(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	if h := readHistory(); h != nil {
+		t.Errorf("expected no history, got %v", h)
+	}
+
+	recordHistory("dev", "us-west-2")
+	recordHistory("staging", "eu-west-1")
+	recordHistory("my team", "eu-north-1")
+
+	h := readHistory()
+	if len(h) != 3 || h[0].Profile != "dev" || h[1].Region != "eu-west-1" || h[2].Profile != "my team" || h[2].Region != "eu-north-1" {
+		t.Errorf("unexpected history %+v", h)
+	}
+}
+
+func TestRecordHistory_Limit(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	var b strings.Builder
+	for range historyLimit {
+		b.WriteString("1700000000 old us-east-1\n")
+	}
+	writeHistory(t, b.String()+"garbage\n")
+
+	recordHistory("dev", "us-west-2")
+	h := readHistory()
+	if len(h) != historyLimit {
+		t.Fatalf("expected %d entries, got %d", historyLimit, len(h))
+	}
+	if last := h[len(h)-1]; last.Profile != "dev" {
+		t.Errorf("expected the newest entry last, got %+v", last)
+	}
+}
+
+func TestRecentPairs(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	writeHistory(t, `1700000000 dev us-west-2
+1700000100 staging eu-west-1
+1700000200 dev us-west-2
+1700000300 dev eu-north-1
+`)
+
+	var got [][2]string
+	for _, p := range recentPairs(10) {
+		got = append(got, [2]string{p.Profile, p.Region})
+	}
+	want := [][2]string{{"dev", "eu-north-1"}, {"dev", "us-west-2"}, {"staging", "eu-west-1"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("recentPairs = %v, want %v", got, want)
+	}
+
+	if n := len(recentPairs(2)); n != 2 {
+		t.Errorf("expected the limit to apply, got %d pairs", n)
+	}
+}
+
+func writeHistory(t *testing.T, content string) {
+	t.Helper()
+	os.MkdirAll(cacheDir(), 0o755)
+	if err := os.WriteFile(historyPath(), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecordHistory_FileLevelContext(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig+"\n[profile bare]\noutput = json\n", "")
+	defer cleanup()
+
+	Run([]string{"awsctx", "p", "staging"})
+	t.Setenv("AWS_PROFILE", "dev")
+	t.Setenv("AWS_REGION", "ap-south-1")
+	Run([]string{"awsctx", "r", "eu-north-1"})
+	Run([]string{"awsctx", "p", "bare"})
+
+	h := readHistory()
+	var got [][2]string
+	for _, e := range h {
+		got = append(got, [2]string{e.Profile, e.Region})
+	}
+	want := [][2]string{{"staging", "eu-west-1"}, {"staging", "eu-north-1"}, {"bare", ""}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("history = %v, want %v", got, want)
+	}
+
+	data, _ := os.ReadFile(historyPath())
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); !strings.HasSuffix(lines[len(lines)-1], " bare -") {
+		t.Errorf("expected '-' for a missing region, got %q", lines[len(lines)-1])
+	}
+}
+
+func TestFrecencyScores_NoRegion(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	writeHistory(t, "1700000000 bare -\n1700000100 old (none)\n1700000200 dev us-west-2\n")
+
+	scores := frecencyScores("region")
+	if len(scores) != 1 || scores["us-west-2"] == 0 {
+		t.Errorf("expected only us-west-2 to be ranked, got %v", scores)
+	}
+	if p := frecencyScores("profile"); p["bare"] == 0 || p["old"] == 0 {
+		t.Errorf("profiles without a region must still be ranked, got %v", p)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 )
@@ -12,6 +13,11 @@ import (
 type iniFile struct {
 	path  string
 	lines []string
+	orig  []string // lines as loaded, for dry-run diffs
+
+	// sections maps canonical section names to their [start, end] line
+	// range. It is built on first lookup and dropped by every edit.
+	sections map[string][2]int
 }
 
 // loadINI reads the file at path into lines.
@@ -32,7 +38,7 @@ func loadINI(path string) (*iniFile, error) {
 
 	// Split lines, removing trailing newline to avoid empty last string
 	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
-	return &iniFile{path: path, lines: lines}, nil
+	return &iniFile{path: path, lines: lines, orig: append([]string(nil), lines...)}, nil
 }
 
 // save writes lines back to the file at f.path.
@@ -51,32 +57,84 @@ func (f *iniFile) save() error {
 	return os.WriteFile(f.path, []byte(content), 0600)
 }
 
+// parseSectionHeader returns the canonical name of the section a line opens,
+// following the AWS CLI: whitespace inside the brackets is insignificant, a
+// trailing # or ; comment is ignored and a quoted name is unquoted, so
+// `[ profile  "dev" ] # note` yields "profile dev".
+func parseSectionHeader(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "[") {
+		return "", false
+	}
+	end := strings.IndexByte(line, ']')
+	if end < 0 {
+		return "", false
+	}
+	if rest := strings.TrimSpace(line[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") && !strings.HasPrefix(rest, ";") {
+		return "", false
+	}
+
+	fields := strings.Fields(line[1:end])
+	if len(fields) == 0 {
+		return "", false
+	}
+	if len(fields) == 1 {
+		return unquote(fields[0]), true
+	}
+	// [profile x], [sso-session x] and [services x] name a x of that kind.
+	return fields[0] + " " + unquote(strings.Join(fields[1:], " ")), true
+}
+
+// unquote strips one pair of matching single or double quotes from s.
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// clone returns a copy of f that can be edited without affecting f.
+func (f *iniFile) clone() *iniFile {
+	return &iniFile{
+		path:     f.path,
+		lines:    append([]string(nil), f.lines...),
+		orig:     f.orig,
+		sections: f.sections,
+	}
+}
+
 // sectionRange finds the line index of the [name] header (start) and the
-// last line of that section's body (end, inclusive).
+// last line of that section's body (end, inclusive). Headers are compared
+// by their canonical names, see parseSectionHeader.
 func (f *iniFile) sectionRange(name string) (start, end int, found bool) {
-	header := "[" + name + "]"
-	start = -1
-	for i, line := range f.lines {
-		if strings.TrimSpace(line) == header {
-			start = i
-			break
-		}
+	if f.sections == nil {
+		f.indexSections()
 	}
-
-	if start == -1 {
+	name, _ = parseSectionHeader("[" + name + "]")
+	r, found := f.sections[name]
+	if !found {
 		return -1, -1, false
 	}
+	return r[0], r[1], true
+}
 
-	end = start
-	for i := start + 1; i < len(f.lines); i++ {
-		line := strings.TrimSpace(f.lines[i])
-		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
-			break
+// indexSections records the range of every section. Only the first of
+// duplicate sections is indexed, as only it is read.
+func (f *iniFile) indexSections() {
+	f.sections = make(map[string][2]int)
+	name, start := "", -1
+	closeSection := func(end int) {
+		if _, dup := f.sections[name]; start >= 0 && !dup {
+			f.sections[name] = [2]int{start, end}
 		}
-		end = i
 	}
-
-	return start, end, true
+	for i, line := range f.lines {
+		if section, ok := parseSectionHeader(line); ok {
+			closeSection(i - 1)
+			name, start = section, i
+		}
+	}
+	closeSection(len(f.lines) - 1)
 }
 
 // hasSection returns true if sectionRange finds the section.
@@ -112,6 +170,7 @@ func (f *iniFile) getKeys(section string) map[string]string {
 
 // setKey replaces or appends a key=value pair in the given section.
 func (f *iniFile) setKey(section, key, value string) {
+	defer f.dropIndex()
 	start, end, found := f.sectionRange(section)
 	newLine := fmt.Sprintf("%s = %s", key, value)
 
@@ -139,6 +198,7 @@ func (f *iniFile) setKey(section, key, value string) {
 
 // replaceSection replaces all keys in the section with the given map.
 func (f *iniFile) replaceSection(name string, keys map[string]string) {
+	defer f.dropIndex()
 	start, end, found := f.sectionRange(name)
 
 	var newBody []string
@@ -152,9 +212,9 @@ func (f *iniFile) replaceSection(name string, keys map[string]string) {
 	}
 
 	if found {
-		// Replace body lines
-		newLines := append(f.lines[:start+1], newBody...)
-		f.lines = append(newLines, f.lines[end+1:]...)
+		// Replace body lines in a new slice: appending to f.lines[:start+1]
+		// would overwrite the following sections before they are copied.
+		f.lines = slices.Concat(f.lines[:start+1], newBody, f.lines[end+1:])
 	} else {
 		// Create section at the end
 		if len(f.lines) > 0 && f.lines[len(f.lines)-1] != "" {
@@ -173,6 +233,7 @@ func (f *iniFile) copySection(srcSection, dstSection string) {
 
 // deleteSection removes the entire section.
 func (f *iniFile) deleteSection(name string) {
+	defer f.dropIndex()
 	start, end, found := f.sectionRange(name)
 	if !found {
 		return
@@ -185,3 +246,8 @@ func (f *iniFile) deleteSection(name string) {
 
 	f.lines = append(f.lines[:start], f.lines[end+1:]...)
 }
+
+// dropIndex forgets the section index after the lines changed.
+func (f *iniFile) dropIndex() {
+	f.sections = nil
+}
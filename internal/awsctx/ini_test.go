@@ -70,6 +70,72 @@ func TestSectionRange(t *testing.T) {
 	}
 }
 
+func TestParseSectionHeader(t *testing.T) {
+	tests := []struct {
+		line   string
+		want   string
+		wantOK bool
+	}{
+		{"[default]", "default", true},
+		{"  [default]  ", "default", true},
+		{"[ default ]", "default", true},
+		{"[profile dev]", "profile dev", true},
+		{"[ profile dev ]", "profile dev", true},
+		{"[profile  dev]", "profile dev", true},
+		{"[profile\tdev]", "profile dev", true},
+		{`[profile "dev"]`, "profile dev", true},
+		{"[profile 'dev']", "profile dev", true},
+		{"[profile default]", "profile default", true},
+		{"[default] # main account", "default", true},
+		{"[profile dev] ; legacy", "profile dev", true},
+		{"[sso-session  corp]", "sso-session corp", true},
+		{"[ci-user]", "ci-user", true},
+		{"[]", "", false},
+		{"[  ]", "", false},
+		{"[default", "", false},
+		{"[default] region = x", "", false},
+		{"region = us-east-1", "", false},
+		{"# [default]", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseSectionHeader(tt.line)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseSectionHeader(%q) = (%q, %v), want (%q, %v)", tt.line, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestSectionRange_HeaderVariants(t *testing.T) {
+	ini := &iniFile{lines: []string{
+		"[ default ] # main",
+		"region = us-east-1",
+		"[profile  dev]",
+		"region = us-west-2",
+		`[ profile "staging" ] ; note`,
+		"region = eu-west-1",
+	}}
+
+	tests := []struct {
+		name      string
+		wantStart int
+		wantEnd   int
+	}{
+		{"default", 0, 1},
+		{"profile dev", 2, 3},
+		{"profile  dev", 2, 3},
+		{"profile staging", 4, 5},
+	}
+
+	for _, tt := range tests {
+		start, end, found := ini.sectionRange(tt.name)
+		if !found || start != tt.wantStart || end != tt.wantEnd {
+			t.Errorf("sectionRange(%q) = (%v, %v, %v), want (%v, %v, true)",
+				tt.name, start, end, found, tt.wantStart, tt.wantEnd)
+		}
+	}
+}
+
 func TestGetKeys(t *testing.T) {
 	ini := &iniFile{lines: strings.Split(strings.TrimSuffix(testINI, "\n"), "\n")}
 
@@ -127,6 +193,25 @@ func TestReplaceSection(t *testing.T) {
 	}
 }
 
+func TestReplaceSection_NotLast(t *testing.T) {
+	ini := &iniFile{lines: strings.Split(strings.TrimSuffix(testINI, "\n"), "\n")}
+	orig := ini.clone()
+
+	// More keys than the old body has lines, so the new body reaches into
+	// the lines of [profile dev].
+	newKeys := map[string]string{"a": "1", "b": "2", "c": "3", "d": "4", "e": "5"}
+	ini.replaceSection("default", newKeys)
+
+	if keys := ini.getKeys("default"); !reflect.DeepEqual(keys, newKeys) {
+		t.Errorf("replaceSection failed, got %v, want %v", keys, newKeys)
+	}
+	for _, section := range []string{"profile dev", "profile staging"} {
+		if got, want := ini.getKeys(section), orig.getKeys(section); !reflect.DeepEqual(got, want) {
+			t.Errorf("[%s] changed: got %v, want %v", section, got, want)
+		}
+	}
+}
+
 func TestCopySection(t *testing.T) {
 	ini := &iniFile{lines: strings.Split(strings.TrimSuffix(testINI, "\n"), "\n")}
 
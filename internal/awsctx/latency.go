@@ -0,0 +1,172 @@
+package awsctx
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultProbeEndpoint is the host:port dialed for each region.
+	// {region} is replaced with the region name.
+	defaultProbeEndpoint = "ec2.{region}.amazonaws.com:443"
+
+	probeTimeout = 2 * time.Second
+	probeWorkers = 8
+
+	// latencyTTL is how long measured round-trip times are shown in listings.
+	latencyTTL = 24 * time.Hour
+)
+
+// probeEndpoint returns the endpoint template, overridable with
+// AWSCTX_PROBE_ENDPOINT (e.g. "127.0.0.1:8443" for a local stand-in).
+func probeEndpoint() string {
+	if e := os.Getenv("AWSCTX_PROBE_ENDPOINT"); e != "" {
+		return e
+	}
+	return defaultProbeEndpoint
+}
+
+// probeRegions measures the TCP connect time to each region's endpoint using
+// a bounded pool of workers. Regions that can't be reached are left out.
+func probeRegions(regions []string, endpoint string) map[string]time.Duration {
+	jobs := make(chan string)
+	results := make(map[string]time.Duration)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i := 0; i < probeWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for region := range jobs {
+				addr := strings.ReplaceAll(endpoint, "{region}", region)
+				start := time.Now()
+				conn, err := net.DialTimeout("tcp", addr, probeTimeout)
+				if err != nil {
+					continue
+				}
+				rtt := time.Since(start)
+				conn.Close()
+
+				mu.Lock()
+				results[region] = rtt
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, r := range regions {
+		jobs <- r
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// nearestRegion returns the region with the lowest round-trip time.
+// Ties are broken by name so the result is stable.
+func nearestRegion(results map[string]time.Duration) string {
+	var names []string
+	for r := range results {
+		names = append(names, r)
+	}
+	sort.Strings(names)
+
+	best := ""
+	for _, r := range names {
+		if best == "" || results[r] < results[best] {
+			best = r
+		}
+	}
+	return best
+}
+
+// readLatency returns cached round-trip times, or nil if none were measured
+// within latencyTTL.
+func readLatency() map[string]time.Duration {
+	path := filepath.Join(cacheDir(), "latency")
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > latencyTTL {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	results := make(map[string]time.Duration)
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		us, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		results[fields[0]] = time.Duration(us) * time.Microsecond
+	}
+	return results
+}
+
+// saveLatency caches measured round-trip times, one "region microseconds" per line.
+func saveLatency(results map[string]time.Duration) {
+	var lines []string
+	for r, d := range results {
+		lines = append(lines, fmt.Sprintf("%s %d", r, d.Microseconds()))
+	}
+	sort.Strings(lines)
+
+	dir := cacheDir()
+	os.MkdirAll(dir, 0o755)
+	os.WriteFile(filepath.Join(dir, "latency"), []byte(strings.Join(lines, "\n")+"\n"), 0o644)
+}
+
+// formatRTT renders a round-trip time for listings.
+func formatRTT(d time.Duration) string {
+	if d < time.Millisecond {
+		return "<1ms"
+	}
+	return fmt.Sprintf("%dms", d.Milliseconds())
+}
+
+// regionLabel returns the region name padded and followed by its cached
+// round-trip time and a pinned marker, or just the name when neither applies.
+func regionLabel(region string, latency map[string]time.Duration, pinned bool) string {
+	var cols []string
+	if d, ok := latency[region]; ok {
+		cols = append(cols, formatRTT(d))
+	}
+	if pinned {
+		cols = append(cols, pinnedMarker)
+	}
+	if len(cols) == 0 {
+		return region
+	}
+	return fmt.Sprintf("%-16s%s", region, strings.Join(cols, "  "))
+}
+
+// findNearestRegion probes every region, caches the results and returns the
+// closest one.
+func findNearestRegion() (string, error) {
+	endpoint := probeEndpoint()
+	fmt.Fprintf(os.Stderr, "Probing %d regions...\n", len(awsRegions))
+
+	results := probeRegions(awsRegions, endpoint)
+	if len(results) == 0 {
+		return "", fmt.Errorf("no region endpoint reachable (%s)", endpoint)
+	}
+	saveLatency(results)
+
+	best := nearestRegion(results)
+	fmt.Fprintf(os.Stderr, "Nearest region: %s (%s)\n", best, formatRTT(results[best]))
+	return best, nil
+}
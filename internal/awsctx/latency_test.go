@@ -0,0 +1,90 @@
+package awsctx
+
+import (
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// startProbeServer starts a local TCP listener standing in for region endpoints.
+func startProbeServer(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestProbeRegions(t *testing.T) {
+	addr := startProbeServer(t)
+
+	regions := []string{"us-east-1", "eu-west-1", "ap-south-1"}
+	results := probeRegions(regions, addr)
+	if len(results) != len(regions) {
+		t.Fatalf("expected %d results, got %d: %v", len(regions), len(results), results)
+	}
+}
+
+func TestProbeRegions_Unreachable(t *testing.T) {
+	// Grab a free port and close it so nothing is listening.
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := ln.Addr().String()
+	ln.Close()
+
+	results := probeRegions([]string{"us-east-1"}, addr)
+	if len(results) != 0 {
+		t.Errorf("expected no results, got %v", results)
+	}
+}
+
+func TestNearestRegion(t *testing.T) {
+	results := map[string]time.Duration{
+		"us-east-1": 80 * time.Millisecond,
+		"eu-west-1": 12 * time.Millisecond,
+		"eu-west-2": 12 * time.Millisecond,
+	}
+	if r := nearestRegion(results); r != "eu-west-1" {
+		t.Errorf("expected eu-west-1, got %s", r)
+	}
+	if r := nearestRegion(nil); r != "" {
+		t.Errorf("expected empty, got %s", r)
+	}
+}
+
+func TestLatencyCache(t *testing.T) {
+	dir := t.TempDir()
+	os.Setenv("XDG_CACHE_HOME", dir)
+	defer os.Unsetenv("XDG_CACHE_HOME")
+
+	if l := readLatency(); l != nil {
+		t.Errorf("expected nil, got %v", l)
+	}
+
+	saveLatency(map[string]time.Duration{"us-east-1": 42 * time.Millisecond})
+	l := readLatency()
+	if l["us-east-1"] != 42*time.Millisecond {
+		t.Errorf("expected 42ms, got %v", l["us-east-1"])
+	}
+}
+
+func TestRegionLabel(t *testing.T) {
+	latency := map[string]time.Duration{"us-east-1": 42 * time.Millisecond}
+	if l := regionLabel("us-east-1", latency, false); l != "us-east-1       42ms" {
+		t.Errorf("unexpected label %q", l)
+	}
+	if l := regionLabel("eu-west-1", latency, false); l != "eu-west-1" {
+		t.Errorf("unexpected label %q", l)
+	}
+}
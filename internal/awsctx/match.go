@@ -0,0 +1,131 @@
+package awsctx
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// maxSuggestions caps the names offered by a "did you mean" hint.
+const maxSuggestions = 3
+
+// matchNames returns the names that partial is a prefix of or, if there are
+// none, the names that contain it, ignoring case. An exact match is the
+// only result.
+func matchNames(partial string, names []string) []string {
+	if slices.Contains(names, partial) {
+		return []string{partial}
+	}
+	p := strings.ToLower(partial)
+	var prefixed, contained []string
+	for _, n := range names {
+		switch l := strings.ToLower(n); {
+		case strings.HasPrefix(l, p):
+			prefixed = append(prefixed, n)
+		case strings.Contains(l, p):
+			contained = append(contained, n)
+		}
+	}
+	if len(prefixed) > 0 {
+		return prefixed
+	}
+	return contained
+}
+
+// suggestNames returns up to maxSuggestions names within a small edit
+// distance of name, closest first.
+func suggestNames(name string, names []string) []string {
+	limit := len(name)/3 + 1
+	type candidate struct {
+		name string
+		dist int
+	}
+	var cs []candidate
+	for _, n := range names {
+		if d := editDistance(strings.ToLower(name), strings.ToLower(n)); d <= limit {
+			cs = append(cs, candidate{n, d})
+		}
+	}
+	slices.SortStableFunc(cs, func(a, b candidate) int { return a.dist - b.dist })
+
+	var out []string
+	for i := 0; i < len(cs) && i < maxSuggestions; i++ {
+		out = append(out, cs[i].name)
+	}
+	return out
+}
+
+// didYouMean returns a hint naming the suggestions for name, or "".
+func didYouMean(name string, names []string) string {
+	s := suggestNames(name, names)
+	if len(s) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("; did you mean %s?", strings.Join(s, ", "))
+}
+
+// editDistance is the number of single-character insertions, deletions,
+// substitutions and adjacent transpositions turning a into b.
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	// d[i][j] is the distance between s[:i] and t[:j].
+	d := make([][]int, len(s)+1)
+	for i := range d {
+		d[i] = make([]int, len(t)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(s)][len(t)]
+}
+
+// profileNames returns the names of all profiles in file order.
+func profileNames() []string {
+	entries, _ := getProfileEntries()
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.Name
+	}
+	return names
+}
+
+// profileNotFound is the error for an unknown profile name.
+func profileNotFound(name string) error {
+	return notFoundErrorf("profile %q not found in %s or %s%s",
+		name, awsConfigPath(), awsCredentialsPath(), didYouMean(name, profileNames()))
+}
+
+// regionNotFound is the error for an unknown region name.
+func regionNotFound(name string) error {
+	return notFoundErrorf("unknown AWS region: %s%s", name, didYouMean(name, awsRegions))
+}
+
+// switchPartial switches to the profile or region (kind) among names that
+// partial refers to, with set. Several matches are offered in the picker
+// filtered by partial, or reported when there is no terminal; no match
+// reports notFound.
+func switchPartial(kind, partial string, names []string, set func(string) error, notFound func(string) error) error {
+	matches := matchNames(partial, names)
+	switch {
+	case len(matches) == 0:
+		return notFound(partial)
+	case len(matches) == 1:
+		return set(matches[0])
+	case canPick():
+		return choose(kind, partial, set)
+	}
+	return fmt.Errorf("%s %q matches several %ss: %s", kind, partial, kind, strings.Join(matches, ", "))
+}
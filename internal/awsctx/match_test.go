@@ -0,0 +1,94 @@
+package awsctx
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatchNames(t *testing.T) {
+	names := []string{"payments-dev", "payments-prod", "dev", "Ops-Admin", "pay"}
+	tests := []struct {
+		partial string
+		want    []string
+	}{
+		{"pay", []string{"pay"}},
+		{"payments-p", []string{"payments-prod"}},
+		{"paym", []string{"payments-dev", "payments-prod"}},
+		{"dev", []string{"dev"}},
+		{"ev", []string{"payments-dev", "dev"}},
+		{"ops", []string{"Ops-Admin"}},
+		{"admin", []string{"Ops-Admin"}},
+		{"xyz", nil},
+	}
+	for _, tt := range tests {
+		if got := matchNames(tt.partial, names); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("matchNames(%q) = %v, want %v", tt.partial, got, tt.want)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"dev", "dev", 0},
+		{"dev", "", 3},
+		{"stagng", "staging", 1},
+		{"pord", "prod", 1},
+		{"eu-wset-1", "eu-west-1", 1},
+		{"kitten", "sitting", 3},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSuggestNames(t *testing.T) {
+	got := suggestNames("eu-wst-1", awsRegions)
+	if len(got) == 0 || got[0] != "eu-west-1" || len(got) > maxSuggestions {
+		t.Errorf("suggestNames(eu-wst-1) = %v", got)
+	}
+	if got := suggestNames("zz", []string{"dev", "prod"}); got != nil {
+		t.Errorf("expected no suggestions for a distant name, got %v", got)
+	}
+}
+
+func TestRun_PartialNames(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, testCredentials)
+	defer cleanup()
+
+	if err := Run([]string{"awsctx", "p", "stag"}); err != nil {
+		t.Fatalf("expected stag to switch to staging, got %v", err)
+	}
+	if got := currentProfile(); got != "staging" {
+		t.Errorf("current profile = %q, want staging", got)
+	}
+	if err := Run([]string{"awsctx", "r", "ca-central"}); err != nil {
+		t.Fatalf("expected ca-central to switch to ca-central-1, got %v", err)
+	}
+	if got := currentRegion(); got != "ca-central-1" {
+		t.Errorf("current region = %q, want ca-central-1", got)
+	}
+
+	tests := []struct {
+		args []string
+		code int
+		msg  string
+	}{
+		{[]string{"awsctx", "p", "de"}, 1, `profile "de" matches several profiles: default, dev`},
+		{[]string{"awsctx", "p", "stagign"}, exitNotFound, "did you mean staging?"},
+		{[]string{"awsctx", "r", "eu-wst-1"}, exitNotFound, "did you mean eu-west-1"},
+		{[]string{"awsctx", "pick", "dve", "us-east-1"}, exitNotFound, "did you mean dev?"},
+	}
+	for _, tt := range tests {
+		err := Run(tt.args)
+		if ExitCode(err) != tt.code || err == nil || !strings.Contains(err.Error(), tt.msg) {
+			t.Errorf("%v: expected exit code %d and %q, got %v", tt.args[1:], tt.code, tt.msg, err)
+		}
+	}
+}
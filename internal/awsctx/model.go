@@ -0,0 +1,118 @@
+package awsctx
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Config is the parsed AWS config and credentials files. It is loaded once
+// per invocation by loadConfig and shared by all lookups; commands that
+// edit a file work on a clone and save it with writeINI.
+type Config struct {
+	config      *iniFile
+	credentials *iniFile
+	entries     []profileEntry
+	missing     error // why the config file could not be opened, if absent
+
+	stamp string // identifies the file versions this was parsed from
+}
+
+// loadedConfig is the Config of the current invocation.
+var loadedConfig *Config
+
+// loadConfig returns the parsed AWS files, reading them only when they are
+// not loaded yet or have changed on disk since.
+func loadConfig() (*Config, error) {
+	stamp := fileStamp(awsConfigPath()) + "\n" + fileStamp(awsCredentialsPath())
+	if loadedConfig != nil && loadedConfig.stamp == stamp {
+		return loadedConfig, nil
+	}
+
+	cfg := &Config{stamp: stamp}
+	var err error
+	if cfg.config, err = loadINI(awsConfigPath()); err != nil {
+		return nil, fmt.Errorf("cannot read AWS config: %w", err)
+	}
+	if _, err := os.Stat(awsConfigPath()); os.IsNotExist(err) {
+		cfg.missing = err
+	}
+	if cfg.credentials, err = loadINI(awsCredentialsPath()); err != nil {
+		return nil, fmt.Errorf("cannot read AWS credentials: %w", err)
+	}
+	cfg.entries = cfg.profileEntries()
+
+	loadedConfig = cfg
+	return cfg, nil
+}
+
+// resetConfig forgets the loaded Config, e.g. after a file was written.
+func resetConfig() {
+	loadedConfig = nil
+}
+
+// fileStamp summarizes the identity and version of the file at path.
+func fileStamp(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return path
+	}
+	return fmt.Sprintf("%s %d %d", path, info.Size(), info.ModTime().UnixNano())
+}
+
+// profileEntries lists the profiles of the config file followed by those
+// defined only in the credentials file; the AWS CLI accepts both.
+func (c *Config) profileEntries() []profileEntry {
+	var entries []profileEntry
+	index := make(map[string]int)
+
+	for _, line := range c.config.lines {
+		section, _ := parseSectionHeader(line)
+		name, ok := configProfileName(section)
+		if _, seen := index[name]; ok && !seen {
+			index[name] = len(entries)
+			entries = append(entries, profileEntry{Name: name, InConfig: true})
+		}
+	}
+
+	for _, line := range c.credentials.lines {
+		// Credentials profiles are named without the "profile " prefix;
+		// [profile x] is invalid here (see doctor) and the backup isn't a profile.
+		name, ok := parseSectionHeader(line)
+		if !ok || strings.HasPrefix(name, "profile ") || name == "_awsctx_original_default" {
+			continue
+		}
+		if i, ok := index[name]; ok {
+			entries[i].InCredentials = true
+			continue
+		}
+		index[name] = len(entries)
+		entries = append(entries, profileEntry{Name: name, InCredentials: true})
+	}
+	return entries
+}
+
+// profileKeys returns a profile's config settings, see profileKeys.
+func (c *Config) profileKeys(name string) map[string]string {
+	return profileKeys(c.config, name)
+}
+
+// hasProfile reports whether name is a profile in either file.
+func (c *Config) hasProfile(name string) bool {
+	for _, e := range c.entries {
+		if e.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// editConfig returns a copy of the config file to modify and save.
+func (c *Config) editConfig() *iniFile {
+	return c.config.clone()
+}
+
+// editCredentials returns a copy of the credentials file to modify and save.
+func (c *Config) editCredentials() *iniFile {
+	return c.credentials.clone()
+}
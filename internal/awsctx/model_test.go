@@ -0,0 +1,106 @@
+package awsctx
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig_ReusedUntilChanged(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, testCredentials)
+	defer cleanup()
+
+	first, err := loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := loadConfig(); again != first {
+		t.Error("expected the loaded config to be reused")
+	}
+
+	if err := switchRegionInConfig("ap-south-1"); err != nil {
+		t.Fatal(err)
+	}
+	after, _ := loadConfig()
+	if after == first {
+		t.Fatal("expected the config to be reloaded after a write")
+	}
+	if got := after.config.getKeys("default")["region"]; got != "ap-south-1" {
+		t.Errorf("reloaded region = %q, want ap-south-1", got)
+	}
+
+	os.WriteFile(awsConfigPath(), []byte("[profile other]\n"), 0o644)
+	if entries, _ := getProfileEntries(); entries[0].Name != "other" {
+		t.Errorf("expected an externally changed file to be reloaded, got %+v", entries)
+	}
+}
+
+func TestLoadConfig_EditsDoNotLeak(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, testCredentials)
+	defer cleanup()
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ini := cfg.editConfig()
+	ini.setKey("default", "region", "ap-south-1")
+	if got := cfg.config.getKeys("default")["region"]; got == "ap-south-1" {
+		t.Error("editing a clone changed the shared config")
+	}
+}
+
+// largeConfig returns config and credentials files with n profiles each.
+func largeConfig(n int) (string, string) {
+	var config, creds strings.Builder
+	config.WriteString("[default]\nregion = us-east-1\n")
+	creds.WriteString("[default]\naws_access_key_id = AKIAEXAMPLE\n")
+	for i := range n {
+		fmt.Fprintf(&config, "\n[profile p%04d]\nregion = eu-west-1\nrole_arn = arn:aws:iam::%012d:role/admin\n", i, i)
+		fmt.Fprintf(&creds, "\n[p%04d]\naws_access_key_id = AKIA%016d\naws_secret_access_key = secret\n", i, i)
+	}
+	return config.String(), creds.String()
+}
+
+func BenchmarkLoadConfig(b *testing.B) {
+	config, creds := largeConfig(5000)
+	cleanup := setupTestAWS(b, config, creds)
+	defer cleanup()
+
+	for b.Loop() {
+		resetConfig()
+		if _, err := loadConfig(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkSwitchLookups runs the lookups of one `awsctx p <name>`
+// invocation against a cold cache.
+func BenchmarkSwitchLookups(b *testing.B) {
+	config, creds := largeConfig(5000)
+	cleanup := setupTestAWS(b, config, creds)
+	defer cleanup()
+
+	for b.Loop() {
+		resetConfig()
+		if !profileExists("p4999") {
+			b.Fatal("profile p4999 not found")
+		}
+		currentProfile()
+		currentRegion()
+		getProfileAccounts([]string{"p4999"})
+	}
+}
+
+func BenchmarkSectionRange(b *testing.B) {
+	config, _ := largeConfig(5000)
+	ini := &iniFile{lines: strings.Split(config, "\n")}
+
+	for b.Loop() {
+		if !ini.hasSection("profile p4999") {
+			b.Fatal("section not found")
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package awsctx
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// outputFormat is set from the global --output flag. "text" is the human
+// listing; the other formats write structured records to stdout.
+var outputFormat = "text"
+
+var outputFormats = []string{"text", "json", "yaml", "tsv"}
+
+// profileRecord is the structured form of a profile. Field order and names
+// are part of the output schema; only append new fields.
+type profileRecord struct {
+	Name         string `json:"name"`
+	Current      bool   `json:"current"`
+	Region       string `json:"region"`
+	Account      string `json:"account"`
+	AccountName  string `json:"account_name"`
+	Source       string `json:"source"`
+	SSOStartURL  string `json:"sso_start_url"`
+	SSOSession   string `json:"sso_session"`
+	SSOAccountID string `json:"sso_account_id"`
+	SSORoleName  string `json:"sso_role_name"`
+	Pinned       bool   `json:"pinned"`
+}
+
+// regionRecord is the structured form of a region. LatencyMS is null unless
+// a measurement from `awsctx r --nearest` is cached.
+type regionRecord struct {
+	Name      string `json:"name"`
+	Current   bool   `json:"current"`
+	LatencyMS *int64 `json:"latency_ms"`
+	Pinned    bool   `json:"pinned"`
+}
+
+// statusRecord is the structured form of `awsctx` without arguments.
+type statusRecord struct {
+	Profile string `json:"profile"`
+	Region  string `json:"region"`
+	Account string `json:"account"`
+}
+
+// identityRecord is the structured form of `awsctx whoami`.
+type identityRecord struct {
+	Profile string `json:"profile"`
+	Account string `json:"account"`
+	Arn     string `json:"arn"`
+	UserID  string `json:"user_id"`
+}
+
+// contextRecord is the structured form of a pair listed by `awsctx pick`.
+type contextRecord struct {
+	Profile string `json:"profile"`
+	Region  string `json:"region"`
+	Current bool   `json:"current"`
+	Recent  bool   `json:"recent"`
+}
+
+// structuredOutput reports whether records should be written instead of text.
+func structuredOutput() bool {
+	return outputFormat != "text"
+}
+
+// writeOutput encodes v, a record struct or a slice of them, in format.
+func writeOutput(w io.Writer, format string, v any) error {
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	case "yaml":
+		return writeYAML(w, v)
+	case "tsv":
+		return writeTSV(w, v)
+	default:
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+}
+
+// recordFields returns the JSON field names and values of a record struct,
+// in declaration order.
+func recordFields(v reflect.Value) (keys []string, values []any) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		keys = append(keys, strings.Split(t.Field(i).Tag.Get("json"), ",")[0])
+		values = append(values, v.Field(i).Interface())
+	}
+	return keys, values
+}
+
+// records normalises v to a slice of struct values.
+func records(v any) (rows []reflect.Value, list bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return []reflect.Value{rv}, false
+	}
+	for i := 0; i < rv.Len(); i++ {
+		rows = append(rows, rv.Index(i))
+	}
+	return rows, true
+}
+
+func writeYAML(w io.Writer, v any) error {
+	rows, list := records(v)
+	if list && len(rows) == 0 {
+		_, err := fmt.Fprintln(w, "[]")
+		return err
+	}
+	var b strings.Builder
+	for _, row := range rows {
+		keys, values := recordFields(row)
+		for i, k := range keys {
+			prefix := ""
+			if list {
+				prefix = "  "
+				if i == 0 {
+					prefix = "- "
+				}
+			}
+			fmt.Fprintf(&b, "%s%s: %s\n", prefix, k, yamlScalar(values[i]))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// yamlScalar renders a value as a YAML scalar. Strings are always quoted so
+// values like account IDs stay strings.
+func yamlScalar(v any) string {
+	switch x := v.(type) {
+	case string:
+		return strconv.Quote(x)
+	case bool:
+		return strconv.FormatBool(x)
+	case *int64:
+		if x == nil {
+			return "null"
+		}
+		return strconv.FormatInt(*x, 10)
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+func writeTSV(w io.Writer, v any) error {
+	rows, _ := records(v)
+
+	// The header comes from the type so an empty list still has one.
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	header, _ := recordFields(reflect.New(t).Elem())
+
+	var b strings.Builder
+	b.WriteString(strings.Join(header, "\t") + "\n")
+	for _, row := range rows {
+		_, values := recordFields(row)
+		cells := make([]string, len(values))
+		for j, val := range values {
+			cells[j] = tsvCell(val)
+		}
+		b.WriteString(strings.Join(cells, "\t") + "\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func tsvCell(v any) string {
+	switch x := v.(type) {
+	case string:
+		return strings.NewReplacer("\\", "\\\\", "\t", "\\t", "\n", "\\n").Replace(x)
+	case *int64:
+		if x == nil {
+			return ""
+		}
+		return strconv.FormatInt(*x, 10)
+	default:
+		return fmt.Sprint(x)
+	}
+}
@@ -0,0 +1,149 @@
+package awsctx
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"testing"
+)
+
+// captureStdout runs fn and returns what it wrote to os.Stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = orig }()
+
+	done := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- data
+	}()
+	fn()
+	w.Close()
+	return string(<-done)
+}
+
+func TestWriteOutput(t *testing.T) {
+	ms := int64(42)
+	regions := []regionRecord{
+		{Name: "us-east-1", Current: true, LatencyMS: &ms},
+		{Name: "eu-west-1"},
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"json", `[
+  {
+    "name": "us-east-1",
+    "current": true,
+    "latency_ms": 42,
+    "pinned": false
+  },
+  {
+    "name": "eu-west-1",
+    "current": false,
+    "latency_ms": null,
+    "pinned": false
+  }
+]
+`},
+		{"yaml", `- name: "us-east-1"
+  current: true
+  latency_ms: 42
+  pinned: false
+- name: "eu-west-1"
+  current: false
+  latency_ms: null
+  pinned: false
+`},
+		{"tsv", "name\tcurrent\tlatency_ms\tpinned\nus-east-1\ttrue\t42\tfalse\neu-west-1\tfalse\t\tfalse\n"},
+	}
+	for _, tt := range tests {
+		var b bytes.Buffer
+		if err := writeOutput(&b, tt.format, regions); err != nil {
+			t.Fatalf("%s: %v", tt.format, err)
+		}
+		if b.String() != tt.want {
+			t.Errorf("%s output mismatch\ngot:\n%s\nwant:\n%s", tt.format, b.String(), tt.want)
+		}
+	}
+}
+
+func TestWriteOutput_SingleAndEmpty(t *testing.T) {
+	var b bytes.Buffer
+	writeOutput(&b, "yaml", statusRecord{Profile: "dev", Region: "us-east-1", Account: "123456789012"})
+	want := "profile: \"dev\"\nregion: \"us-east-1\"\naccount: \"123456789012\"\n"
+	if b.String() != want {
+		t.Errorf("yaml single record\ngot:\n%s\nwant:\n%s", b.String(), want)
+	}
+
+	b.Reset()
+	writeOutput(&b, "tsv", []profileRecord{})
+	if b.String() != "name\tcurrent\tregion\taccount\taccount_name\tsource\tsso_start_url\tsso_session\tsso_account_id\tsso_role_name\tpinned\n" {
+		t.Errorf("tsv of an empty list should still have a header, got %q", b.String())
+	}
+
+	b.Reset()
+	writeOutput(&b, "json", []profileRecord{})
+	if b.String() != "[]\n" {
+		t.Errorf("json of an empty list should be [], got %q", b.String())
+	}
+}
+
+func TestRun_OutputJSON(t *testing.T) {
+	cleanup := setupTestAWS(t, testAccountsConfig, "")
+	defer cleanup()
+
+	out := captureStdout(t, func() {
+		if err := Run([]string{"awsctx", "p", "-o", "json"}); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	var records []profileRecord
+	if err := json.Unmarshal([]byte(out), &records); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out, err)
+	}
+	if len(records) != 5 {
+		t.Fatalf("expected 5 profiles, got %d", len(records))
+	}
+	if !records[0].Current || records[0].Name != "default" || records[0].Region != "eu-west-1" {
+		t.Errorf("unexpected default record: %+v", records[0])
+	}
+	if records[1].SSOAccountID != "111111111111" || records[1].Account != "111111111111" || records[1].SSORoleName != "Admin" {
+		t.Errorf("unexpected prod record: %+v", records[1])
+	}
+	if records[1].Source != awsConfigPath() {
+		t.Errorf("expected source %s, got %s", awsConfigPath(), records[1].Source)
+	}
+}
+
+func TestRun_OutputStatusAndCurrent(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	out := captureStdout(t, func() { Run([]string{"awsctx", "--output=json"}) })
+	var status statusRecord
+	if err := json.Unmarshal([]byte(out), &status); err != nil || status.Profile != "default" || status.Region != "eu-west-1" {
+		t.Errorf("unexpected status %q (%v)", out, err)
+	}
+
+	out = captureStdout(t, func() { Run([]string{"awsctx", "r", "-c", "-o", "tsv"}) })
+	if out != "name\tcurrent\tlatency_ms\tpinned\neu-west-1\ttrue\t\tfalse\n" {
+		t.Errorf("unexpected region -c tsv %q", out)
+	}
+
+	out = captureStdout(t, func() { Run([]string{"awsctx", "r", "-o", "json"}) })
+	var regions []regionRecord
+	if err := json.Unmarshal([]byte(out), &regions); err != nil || len(regions) != len(awsRegions) {
+		t.Errorf("unexpected region list %q (%v)", out, err)
+	}
+}
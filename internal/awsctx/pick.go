@@ -0,0 +1,195 @@
+package awsctx
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// recentPairLimit is how many recent pairs are listed first by pick.
+const recentPairLimit = 10
+
+func newPickCommand() *command {
+	return &command{
+		Name:  "pick",
+		Args:  "[<profile> <region>]",
+		Short: "switch profile and region together",
+		Long: `
+Without arguments, picks a profile and region pair in one interactive session;
+pairs recently switched to are listed first. With a profile and a region, or
+after picking, both are switched with a single write of the config file.`,
+		Examples: []string{
+			"awsctx pick                  # pick a profile and region",
+			"awsctx pick prod eu-west-1   # switch to prod in eu-west-1",
+		},
+		Run: runPick,
+		Complete: func(args []string) []completion {
+			switch len(args) {
+			case 0:
+				return profileCompletions()
+			case 1:
+				return regionCompletions()
+			}
+			return nil
+		},
+	}
+}
+
+func runPick(in *invocation, args []string) error {
+	switch len(args) {
+	case 0:
+		if !canPick() {
+			return listContexts(sortFor(false))
+		}
+		return choose("pick", "", func(choice string) error {
+			profile, region := splitPair(choice)
+			return setContext(profile, region)
+		})
+	case 2:
+		return setContext(args[0], args[1])
+	default:
+		return usageErrorf("pick takes a profile and a region, or no arguments")
+	}
+}
+
+// contextPair is a profile and region offered by pick.
+type contextPair struct {
+	Profile string
+	Region  string
+	Recent  bool
+}
+
+// contextPairs lists the recent pairs from the history followed by every
+// other profile and region combination, with profiles in the given order
+// and each profile's own region first.
+func contextPairs(order string) ([]contextPair, error) {
+	entries, err := getProfileEntries()
+	if err != nil {
+		return nil, err
+	}
+	entries = sortedProfiles(entries, order)
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	var pairs []contextPair
+	seen := make(map[[2]string]bool)
+	add := func(profile, region string, recent bool) {
+		key := [2]string{profile, region}
+		if !seen[key] {
+			seen[key] = true
+			pairs = append(pairs, contextPair{Profile: profile, Region: region, Recent: recent})
+		}
+	}
+
+	for _, h := range recentPairs(recentPairLimit) {
+		if cfg.hasProfile(h.Profile) && isValidRegion(h.Region) {
+			add(h.Profile, h.Region, true)
+		}
+	}
+	regions := sortedRegions(order)
+	for _, e := range entries {
+		if r := cfg.profileKeys(e.Name)["region"]; isValidRegion(r) {
+			add(e.Name, r, false)
+		}
+		for _, r := range regions {
+			add(e.Name, r, false)
+		}
+	}
+	return pairs, nil
+}
+
+// contextLabels returns one "profile  region" line per pair, with the
+// profile column padded to the longest name.
+func contextLabels(pairs []contextPair) []string {
+	width := 0
+	for _, p := range pairs {
+		width = max(width, len(p.Profile))
+	}
+	labels := make([]string, len(pairs))
+	for i, p := range pairs {
+		labels[i] = fmt.Sprintf("%-*s  %s", width, p.Profile, p.Region)
+	}
+	return labels
+}
+
+// splitPair splits a "profile region" choice at its last space, since
+// profile names may contain spaces and region names never do.
+func splitPair(choice string) (profile, region string) {
+	i := strings.LastIndexByte(choice, ' ')
+	if i < 0 {
+		return choice, ""
+	}
+	return choice[:i], choice[i+1:]
+}
+
+func listContexts(order string) error {
+	pairs, err := contextPairs(order)
+	if err != nil {
+		return err
+	}
+	curProfile, curRegion := currentProfile(), currentRegion()
+
+	if structuredOutput() {
+		records := make([]contextRecord, len(pairs))
+		for i, p := range pairs {
+			records[i] = contextRecord{
+				Profile: p.Profile,
+				Region:  p.Region,
+				Current: p.Profile == curProfile && p.Region == curRegion,
+				Recent:  p.Recent,
+			}
+		}
+		return writeOutput(os.Stdout, outputFormat, records)
+	}
+
+	for i, label := range contextLabels(pairs) {
+		if pairs[i].Profile == curProfile && pairs[i].Region == curRegion {
+			label = highlight(os.Stdout, label)
+		}
+		fmt.Println(listingLine(pairs[i].Profile+"\t"+pairs[i].Region, label))
+	}
+	return nil
+}
+
+// setContext switches to profile and region at once. Both are validated
+// before anything is written, and the config file is written only once.
+func setContext(profile, region string) error {
+	if !profileExists(profile) {
+		return profileNotFound(profile)
+	}
+	if !isValidRegion(region) {
+		return regionNotFound(region)
+	}
+
+	prevProfile, prevRegion := currentProfile(), currentRegion()
+
+	if err := switchContextInConfig(profile, region); err != nil {
+		return err
+	}
+	if err := switchProfileInCredentials(profile); err != nil {
+		return err
+	}
+
+	if dryRun {
+		fmt.Fprintf(os.Stderr, "Dry run: would switch to profile: %s, region: %s\n", profile, region)
+		return nil
+	}
+
+	if prevProfile != profile {
+		savePrevious("profile", prevProfile)
+	}
+	saveState("profile", profile)
+	if prevRegion != region && prevRegion != "(none)" {
+		savePrevious("region", prevRegion)
+	}
+	saveState("region", region)
+	recordHistory(profile, region)
+	saveContext(profile, region)
+
+	fmt.Fprintf(os.Stderr, "Switched to profile: %s, region: %s\n", profile, region)
+	warnShadowing("profile")
+	warnShadowing("region")
+	return nil
+}
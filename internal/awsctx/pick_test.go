@@ -0,0 +1,127 @@
+package awsctx
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestContextPairs(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	writeHistory(t, `1700000000 staging ap-south-1
+1700000100 gone us-east-1
+1700000200 dev mars-1
+`)
+
+	pairs, err := contextPairs("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 3 * len(awsRegions); len(pairs) != want {
+		t.Errorf("expected %d pairs, got %d", want, len(pairs))
+	}
+	if p := pairs[0]; p != (contextPair{Profile: "staging", Region: "ap-south-1", Recent: true}) {
+		t.Errorf("expected the recent pair first, got %+v", p)
+	}
+	// Unknown profiles and regions from the history are skipped; each
+	// profile then starts with its own region.
+	if p := pairs[1]; p != (contextPair{Profile: "default", Region: "eu-west-1"}) {
+		t.Errorf("expected default's own region next, got %+v", p)
+	}
+}
+
+func TestSetContext(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, testCredentials)
+	defer cleanup()
+
+	if err := setContext("dev", "ap-northeast-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := mustLoadINI(t, awsConfigPath()).getKeys("default")
+	if keys["region"] != "ap-northeast-1" || keys["output"] != "yaml" {
+		t.Errorf("config [default] = %v", keys)
+	}
+	if id := mustLoadINI(t, awsCredentialsPath()).getKeys("default")["aws_access_key_id"]; id != "AKIADEV" {
+		t.Errorf("credentials [default] key = %s", id)
+	}
+	if p, r := readState("profile"), readState("region"); p != "dev" || r != "ap-northeast-1" {
+		t.Errorf("state = %s, %s", p, r)
+	}
+	if h := readHistory(); len(h) != 1 || h[0].Profile != "dev" || h[0].Region != "ap-northeast-1" {
+		t.Errorf("history = %+v", h)
+	}
+}
+
+func TestSetContext_ValidatesFirst(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, testCredentials)
+	defer cleanup()
+
+	tests := []struct{ profile, region string }{
+		{"nope", "us-east-1"},
+		{"dev", "mars-1"},
+	}
+	for _, tt := range tests {
+		err := setContext(tt.profile, tt.region)
+		if ExitCode(err) != exitNotFound {
+			t.Errorf("setContext(%s, %s): expected not found, got %v", tt.profile, tt.region, err)
+		}
+	}
+	if data, _ := os.ReadFile(awsConfigPath()); string(data) != testConfig {
+		t.Errorf("config changed after a failed switch:\n%s", data)
+	}
+}
+
+func TestRun_Pick(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, testCredentials)
+	defer cleanup()
+
+	if err := Run([]string{"awsctx", "pick", "staging", "us-east-2"}); err != nil {
+		t.Fatal(err)
+	}
+	if p, r := currentProfile(), currentRegion(); p != "staging" || r != "us-east-2" {
+		t.Errorf("current = %s, %s", p, r)
+	}
+
+	if err := Run([]string{"awsctx", "pick", "staging"}); ExitCode(err) != exitUsage {
+		t.Errorf("expected a usage error for one argument, got %v", err)
+	}
+
+	out := captureStdout(t, func() {
+		if err := Run([]string{"awsctx", "pick", "-o", "json"}); err != nil {
+			t.Error(err)
+		}
+	})
+	var records []contextRecord
+	if err := json.Unmarshal([]byte(out), &records); err != nil {
+		t.Fatal(err)
+	}
+	want := contextRecord{Profile: "staging", Region: "us-east-2", Current: true, Recent: true}
+	if records[0] != want {
+		t.Errorf("first record = %+v, want %+v", records[0], want)
+	}
+}
+
+func TestRun_SwitchesRecordHistory(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, testCredentials)
+	defer cleanup()
+
+	Run([]string{"awsctx", "p", "dev"})
+	Run([]string{"awsctx", "r", "eu-north-1"})
+
+	h := readHistory()
+	if len(h) != 2 || h[0].Profile != "dev" || h[1].Profile != "dev" || h[1].Region != "eu-north-1" {
+		t.Errorf("history = %+v", h)
+	}
+}
+
+func mustLoadINI(t *testing.T, path string) *iniFile {
+	t.Helper()
+	ini, err := loadINI(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ini
+}
@@ -0,0 +1,401 @@
+package awsctx
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// pickerModes are the accepted values of AWSCTX_PICKER. Unset, fzf is used
+// when it is on PATH and the built-in picker otherwise.
+var pickerModes = []string{"builtin", "fzf", "none"}
+
+// pickerMode returns the interactive picker to use.
+func pickerMode() (string, error) {
+	mode := os.Getenv("AWSCTX_PICKER")
+	switch {
+	case mode == "":
+		if hasFzf() {
+			return "fzf", nil
+		}
+		return "builtin", nil
+	case !contains(pickerModes, mode):
+		return "", fmt.Errorf("invalid AWSCTX_PICKER %q (want %s)", mode, strings.Join(pickerModes, ", "))
+	case mode == "fzf" && !hasFzf():
+		return "", fmt.Errorf("AWSCTX_PICKER=fzf but fzf is not on PATH")
+	}
+	return mode, nil
+}
+
+// canPick reports whether a listing should be replaced by an interactive
+// picker. The built-in picker also needs to read keys from a terminal.
+func canPick() bool {
+	if !isInteractive() || structuredOutput() {
+		return false
+	}
+	mode, err := pickerMode()
+	if err != nil {
+		// Let runPicker report the problem.
+		return true
+	}
+	return mode != "none" && (mode == "fzf" || term.IsTerminal(int(os.Stdin.Fd())))
+}
+
+// runPicker lets the user choose a profile, region or, for "pick", a
+// "profile region" pair (kind) with the configured picker, starting with
+// query typed in. A query that matches a single entry selects it without
+// asking. fzf also accepts a choice with one of keys, which is returned;
+// Enter returns an empty key. The choice is empty if the user cancelled.
+func runPicker(kind string, keys []string, query string) (key, choice string, err error) {
+	mode, err := pickerMode()
+	if err != nil {
+		return "", "", err
+	}
+	if mode == "fzf" {
+		return runFzf(kind, keys, query)
+	}
+	items, err := pickItems(kind)
+	if err != nil {
+		return "", "", err
+	}
+	choice, err = runBuiltinPicker(items, query)
+	return "", choice, err
+}
+
+// pickItem is one selectable line of the built-in picker.
+type pickItem struct {
+	Name    string
+	Label   string // the listing line, matched against the query
+	Current bool
+}
+
+// pickItems returns the same entries as the profile, region or pick listing.
+func pickItems(kind string) ([]pickItem, error) {
+	var items []pickItem
+	switch kind {
+	case "profile":
+		entries, err := getProfileEntries()
+		if err != nil {
+			return nil, err
+		}
+		entries = sortedProfiles(entries, sortFor(true))
+		cur := currentProfile()
+		for i, label := range profileLabels(entries) {
+			items = append(items, pickItem{Name: entries[i].Name, Label: label, Current: entries[i].Name == cur})
+		}
+	case "region":
+		cur, latency, pins := currentRegion(), readLatency(), pinnedSet("region")
+		for _, r := range sortedRegions(sortFor(true)) {
+			items = append(items, pickItem{Name: r, Label: regionLabel(r, latency, pins[r]), Current: r == cur})
+		}
+	case "pick":
+		pairs, err := contextPairs(sortFor(true))
+		if err != nil {
+			return nil, err
+		}
+		curProfile, curRegion := currentProfile(), currentRegion()
+		for i, label := range contextLabels(pairs) {
+			p := pairs[i]
+			items = append(items, pickItem{
+				Name:    p.Profile + " " + p.Region,
+				Label:   label,
+				Current: p.Profile == curProfile && p.Region == curRegion,
+			})
+		}
+	default:
+		return nil, fmt.Errorf("unknown picker kind: %s", kind)
+	}
+	return items, nil
+}
+
+// pickerHeight is the maximum number of entries shown at once.
+const pickerHeight = 15
+
+// runBuiltinPicker shows items below the cursor on stderr and reads keys
+// from the terminal on stdin until the user selects or cancels.
+func runBuiltinPicker(items []pickItem, query string) (string, error) {
+	p := newPicker(items, pickerHeight, query)
+	if query != "" && len(p.matches) == 1 {
+		return p.choice(), nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", fmt.Errorf("cannot start picker: %w", err)
+	}
+	defer term.Restore(fd, state)
+
+	width, _, err := term.GetSize(int(os.Stderr.Fd()))
+	if err != nil || width <= 0 {
+		width = 80
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	color := useColor(os.Stderr)
+	defer fmt.Fprint(os.Stderr, "\r\033[J")
+	for {
+		p.render(os.Stderr, width, color)
+		k, err := readKey(in)
+		if err != nil {
+			return "", err
+		}
+		if done := p.handle(k); done {
+			return p.choice(), nil
+		}
+	}
+}
+
+// picker is the state of the built-in picker, independent of the terminal.
+type picker struct {
+	items     []pickItem
+	height    int
+	query     []rune
+	matches   []int // indices into items, best match first
+	selected  int   // index into matches
+	offset    int   // first visible match
+	cancelled bool
+}
+
+// newPicker returns a picker over items filtered by query. Without a query,
+// the current entry is selected.
+func newPicker(items []pickItem, height int, query string) *picker {
+	p := &picker{items: items, height: height, query: []rune(query)}
+	p.filter()
+	if query == "" {
+		for i, it := range items {
+			if it.Current {
+				p.selected = i
+			}
+		}
+	}
+	p.scroll()
+	return p
+}
+
+// filter recomputes the matches for the query, best first; entries that
+// score the same keep their listing order.
+func (p *picker) filter() {
+	type match struct{ index, score int }
+	var ms []match
+	for i, it := range p.items {
+		if score, ok := fuzzyScore(string(p.query), it.Label); ok {
+			ms = append(ms, match{i, score})
+		}
+	}
+	slices.SortStableFunc(ms, func(a, b match) int { return b.score - a.score })
+
+	p.matches = p.matches[:0]
+	for _, m := range ms {
+		p.matches = append(p.matches, m.index)
+	}
+	p.selected, p.offset = 0, 0
+}
+
+// scroll keeps the selected entry within the visible window.
+func (p *picker) scroll() {
+	if p.selected < p.offset {
+		p.offset = p.selected
+	}
+	if p.selected >= p.offset+p.height {
+		p.offset = p.selected - p.height + 1
+	}
+}
+
+// handle applies a key and reports whether the picker is done.
+func (p *picker) handle(k key) bool {
+	switch k {
+	case keyEnter:
+		return true
+	case keyEsc:
+		p.cancelled = true
+		return true
+	case keyUp:
+		p.selected = max(p.selected-1, 0)
+	case keyDown:
+		p.selected = min(p.selected+1, max(len(p.matches)-1, 0))
+	case keyPageUp:
+		p.selected = max(p.selected-p.height, 0)
+	case keyPageDown:
+		p.selected = min(p.selected+p.height, max(len(p.matches)-1, 0))
+	case keyBackspace:
+		if len(p.query) > 0 {
+			p.query = p.query[:len(p.query)-1]
+			p.filter()
+		}
+	case keyClear:
+		p.query = p.query[:0]
+		p.filter()
+	default:
+		if r, size := utf8.DecodeRuneInString(string(k)); size == len(k) && unicode.IsPrint(r) {
+			p.query = append(p.query, r)
+			p.filter()
+		}
+	}
+	p.scroll()
+	return false
+}
+
+// choice returns the selected name, or "" if cancelled or nothing matches.
+func (p *picker) choice() string {
+	if p.cancelled || len(p.matches) == 0 {
+		return ""
+	}
+	return p.items[p.matches[p.selected]].Name
+}
+
+// render draws the prompt and the visible matches, then puts the cursor
+// back after the query. Lines are cut to width so none of them wraps.
+func (p *picker) render(w io.Writer, width int, color bool) {
+	var b strings.Builder
+	b.WriteString("\r\033[J> " + string(p.query))
+	fmt.Fprintf(&b, "  %d/%d", len(p.matches), len(p.items))
+
+	end := min(p.offset+p.height, len(p.matches))
+	for i := p.offset; i < end; i++ {
+		it := p.items[p.matches[i]]
+		line := truncate(it.Label, width-2)
+		switch {
+		case color && i == p.selected:
+			line = selectedStyle + line + resetStyle
+		case color && it.Current:
+			line = highlightStyle + line + resetStyle
+		}
+		marker := "  "
+		if i == p.selected {
+			marker = "> "
+		}
+		b.WriteString("\r\n" + marker + line)
+	}
+
+	if n := end - p.offset; n > 0 {
+		fmt.Fprintf(&b, "\033[%dA", n)
+	}
+	fmt.Fprintf(&b, "\r\033[%dC", 2+len(p.query))
+	io.WriteString(w, b.String())
+}
+
+// truncate cuts s to at most n runes.
+func truncate(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
+
+// fuzzyScore reports whether the runes of pattern appear in s in order,
+// ignoring case. Matches score higher when runes are adjacent or start a
+// word, so "ew1" ranks eu-west-1 above us-east-1.
+func fuzzyScore(pattern, s string) (int, bool) {
+	text := []rune(strings.ToLower(s))
+	score, pos, prev := 0, 0, -2
+	for _, r := range strings.ToLower(pattern) {
+		for pos < len(text) && text[pos] != r {
+			pos++
+		}
+		if pos == len(text) {
+			return 0, false
+		}
+		score++
+		if pos == prev+1 {
+			score += 2
+		}
+		if pos == 0 || strings.ContainsRune(" -_./:", text[pos-1]) {
+			score += 3
+		}
+		prev = pos
+		pos++
+	}
+	return score, true
+}
+
+// key is a key press: one of the named keys below or a single character.
+type key string
+
+const (
+	keyEnter     key = "enter"
+	keyEsc       key = "esc"
+	keyUp        key = "up"
+	keyDown      key = "down"
+	keyPageUp    key = "pgup"
+	keyPageDown  key = "pgdown"
+	keyBackspace key = "backspace"
+	keyClear     key = "ctrl-u"
+	keyNone      key = ""
+)
+
+// readKey reads one key press from a terminal in raw mode.
+func readKey(r *bufio.Reader) (key, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return keyNone, err
+	}
+	switch b {
+	case '\r', '\n':
+		return keyEnter, nil
+	case 3, 7: // ctrl-c, ctrl-g
+		return keyEsc, nil
+	case 127, 8:
+		return keyBackspace, nil
+	case 21:
+		return keyClear, nil
+	case 16, 11: // ctrl-p, ctrl-k
+		return keyUp, nil
+	case 14: // ctrl-n
+		return keyDown, nil
+	case 0x1b:
+		return readEscape(r)
+	}
+	if b < 0x20 {
+		return keyNone, nil
+	}
+	r.UnreadByte()
+	c, _, err := r.ReadRune()
+	if err != nil {
+		return keyNone, err
+	}
+	return key(string(c)), nil
+}
+
+// readEscape decodes the rest of an escape sequence. A lone ESC (nothing
+// else buffered) cancels.
+func readEscape(r *bufio.Reader) (key, error) {
+	if r.Buffered() == 0 {
+		return keyEsc, nil
+	}
+	if b, _ := r.ReadByte(); b != '[' && b != 'O' {
+		return keyNone, nil
+	}
+	seq := ""
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return keyNone, err
+		}
+		seq += string(b)
+		if b >= 0x40 && b <= 0x7e {
+			break
+		}
+	}
+	switch seq {
+	case "A":
+		return keyUp, nil
+	case "B":
+		return keyDown, nil
+	case "5~":
+		return keyPageUp, nil
+	case "6~":
+		return keyPageDown, nil
+	}
+	return keyNone, nil
+}
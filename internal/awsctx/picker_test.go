@@ -0,0 +1,218 @@
+package awsctx
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		wantOK     bool
+	}{
+		{"", "dev", true},
+		{"dev", "dev", true},
+		{"DEV", "my-dev", true},
+		{"ew1", "eu-west-1", true},
+		{"pd", "prod", true},
+		{"dp", "prod", false},
+		{"devx", "dev", false},
+	}
+	for _, tt := range tests {
+		if _, ok := fuzzyScore(tt.pattern, tt.s); ok != tt.wantOK {
+			t.Errorf("fuzzyScore(%q, %q) ok = %v, want %v", tt.pattern, tt.s, ok, tt.wantOK)
+		}
+	}
+
+	adjacent, _ := fuzzyScore("west", "eu-west-1")
+	scattered, _ := fuzzyScore("west", "us-east-1 sw t")
+	if adjacent <= scattered {
+		t.Errorf("adjacent match scored %d, want more than scattered %d", adjacent, scattered)
+	}
+}
+
+func testPickItems(names ...string) []pickItem {
+	items := make([]pickItem, len(names))
+	for i, n := range names {
+		items[i] = pickItem{Name: n, Label: n}
+	}
+	return items
+}
+
+func TestPicker_Filter(t *testing.T) {
+	p := newPicker(testPickItems("us-east-1", "us-west-2", "eu-west-1", "ap-south-1"), 10, "")
+	for _, k := range []key{"w", "e", "s", "t"} {
+		p.handle(k)
+	}
+
+	var got []string
+	for _, i := range p.matches {
+		got = append(got, p.items[i].Name)
+	}
+	if want := []string{"us-west-2", "eu-west-1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("matches for %q = %v, want %v", string(p.query), got, want)
+	}
+	if c := p.choice(); c != "us-west-2" {
+		t.Errorf("choice = %q, want us-west-2", c)
+	}
+}
+
+func TestPicker_Keys(t *testing.T) {
+	items := testPickItems("default", "dev", "prod", "staging")
+	items[2].Current = true
+
+	tests := []struct {
+		name string
+		keys []key
+		want string
+	}{
+		{"starts on current", []key{keyEnter}, "prod"},
+		{"down", []key{keyDown, keyEnter}, "staging"},
+		{"down stops at end", []key{keyDown, keyDown, keyDown, keyEnter}, "staging"},
+		{"up", []key{keyUp, keyUp, keyEnter}, "default"},
+		{"query resets selection", []key{"d", keyEnter}, "default"},
+		{"backspace", []key{"s", "t", keyBackspace, keyBackspace, keyEnter}, "default"},
+		{"clear", []key{"s", "t", keyClear, keyDown, keyEnter}, "dev"},
+		{"no match", []key{"x", "y", "z", keyEnter}, ""},
+		{"cancel", []key{keyDown, keyEsc}, ""},
+	}
+	for _, tt := range tests {
+		p := newPicker(items, 10, "")
+		for _, k := range tt.keys {
+			if p.handle(k) {
+				break
+			}
+		}
+		if got := p.choice(); got != tt.want {
+			t.Errorf("%s: choice = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPicker_Scroll(t *testing.T) {
+	p := newPicker(testPickItems("a", "b", "c", "d", "e"), 2, "")
+	for range 3 {
+		p.handle(keyDown)
+	}
+	if p.selected != 3 || p.offset != 2 {
+		t.Errorf("selected, offset = %d, %d; want 3, 2", p.selected, p.offset)
+	}
+	p.handle(keyPageUp)
+	if p.selected != 1 || p.offset != 1 {
+		t.Errorf("after page up: selected, offset = %d, %d; want 1, 1", p.selected, p.offset)
+	}
+}
+
+func TestPicker_Render(t *testing.T) {
+	p := newPicker(testPickItems("dev", "production-account-with-a-long-name"), 10, "")
+	p.handle("p")
+
+	var b bytes.Buffer
+	p.render(&b, 12, false)
+	out := b.String()
+	if !strings.Contains(out, "> p  1/2") {
+		t.Errorf("missing prompt and count in %q", out)
+	}
+	if !strings.Contains(out, "\r\n> production") || strings.Contains(out, "account") {
+		t.Errorf("expected the selected entry cut to the width in %q", out)
+	}
+	if !strings.HasSuffix(out, "\033[1A\r\033[3C") {
+		t.Errorf("expected the cursor back after the query in %q", out)
+	}
+}
+
+func TestReadKey(t *testing.T) {
+	input := "a\x1b[A\x1b[B\x1b[5~\r\x7f\x15\x03é"
+	r := bufio.NewReader(strings.NewReader(input))
+
+	want := []key{"a", keyUp, keyDown, keyPageUp, keyEnter, keyBackspace, keyClear, keyEsc, "é"}
+	for _, w := range want {
+		k, err := readKey(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if k != w {
+			t.Errorf("readKey = %q, want %q", k, w)
+		}
+	}
+}
+
+func TestPickerMode(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PATH", dir)
+
+	tests := []struct {
+		env     string
+		fzf     bool
+		want    string
+		wantErr bool
+	}{
+		{"", false, "builtin", false},
+		{"", true, "fzf", false},
+		{"builtin", true, "builtin", false},
+		{"none", true, "none", false},
+		{"fzf", true, "fzf", false},
+		{"fzf", false, "", true},
+		{"dialog", true, "", true},
+	}
+	for _, tt := range tests {
+		os.Remove(filepath.Join(dir, "fzf"))
+		if tt.fzf {
+			os.WriteFile(filepath.Join(dir, "fzf"), []byte("#!/bin/sh\n"), 0o755)
+		}
+		t.Setenv("AWSCTX_PICKER", tt.env)
+
+		got, err := pickerMode()
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("AWSCTX_PICKER=%q fzf=%v: got (%q, %v), want %q", tt.env, tt.fzf, got, err, tt.want)
+		}
+	}
+}
+
+func TestPickItems(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, testCredentials)
+	defer cleanup()
+
+	items, err := pickItems("profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, it := range items {
+		names = append(names, it.Name)
+		if it.Current != (it.Name == "default") {
+			t.Errorf("%s: Current = %v", it.Name, it.Current)
+		}
+	}
+	if want := []string{"default", "dev", "staging"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("profile items = %v, want %v", names, want)
+	}
+
+	items, err = pickItems("region")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != len(awsRegions) {
+		t.Errorf("got %d region items, want %d", len(items), len(awsRegions))
+	}
+}
+
+func TestPicker_Query(t *testing.T) {
+	items := testPickItems("dev", "prod", "prod-ro", "staging")
+	items[0].Current = true
+
+	p := newPicker(items, 10, "prod")
+	if p.choice() != "prod" || len(p.matches) != 2 {
+		t.Errorf("expected prod and prod-ro for the query, got %d matches choosing %q", len(p.matches), p.choice())
+	}
+
+	choice, err := runBuiltinPicker(items, "stag")
+	if err != nil || choice != "staging" {
+		t.Errorf("expected a single match to be chosen without asking, got %q, %v", choice, err)
+	}
+}
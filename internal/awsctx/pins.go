@@ -0,0 +1,137 @@
+package awsctx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// pinnedMarker flags pinned entries in listings.
+const pinnedMarker = "(pinned)"
+
+// newPinCommands returns the pin and unpin subcommands for profiles or
+// regions (kind). names completes the names that can be pinned and exists
+// reports a not found error for unknown ones.
+func newPinCommands(kind string, names func() []completion, exists func(name string) error) []*command {
+	return []*command{
+		{
+			Name:     "pin",
+			Args:     "<name>",
+			Short:    "pin a " + kind + " to the top of listings",
+			Complete: firstArg(names),
+			Run: func(in *invocation, args []string) error {
+				if len(args) != 1 {
+					return usageErrorf("pin takes one %s name", kind)
+				}
+				if err := exists(args[0]); err != nil {
+					return err
+				}
+				return pin(kind, args[0])
+			},
+		},
+		{
+			Name:     "unpin",
+			Args:     "<name>",
+			Short:    "remove a pinned " + kind,
+			Complete: firstArg(func() []completion { return pinCompletions(kind) }),
+			Run: func(in *invocation, args []string) error {
+				if len(args) != 1 {
+					return usageErrorf("unpin takes one %s name", kind)
+				}
+				return unpin(kind, args[0])
+			},
+		},
+	}
+}
+
+func pinsPath(kind string) string {
+	return filepath.Join(cacheDir(), "pinned_"+kind)
+}
+
+// readPins returns the pinned profiles or regions (kind) in pin order. The
+// file has one name per line, as profile names may contain spaces.
+func readPins(kind string) []string {
+	data, err := os.ReadFile(pinsPath(kind))
+	if err != nil {
+		return nil
+	}
+	var pins []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if name := strings.TrimSpace(line); name != "" {
+			pins = append(pins, name)
+		}
+	}
+	return pins
+}
+
+// savePins replaces the pinned profiles or regions.
+func savePins(kind string, names []string) error {
+	if err := os.MkdirAll(cacheDir(), 0o755); err != nil {
+		return err
+	}
+	data := strings.Join(names, "\n")
+	if len(names) > 0 {
+		data += "\n"
+	}
+	return os.WriteFile(pinsPath(kind), []byte(data), 0o644)
+}
+
+// pinnedSet returns the pinned names of kind for lookups.
+func pinnedSet(kind string) map[string]bool {
+	set := make(map[string]bool)
+	for _, name := range readPins(kind) {
+		set[name] = true
+	}
+	return set
+}
+
+func pin(kind, name string) error {
+	pins := readPins(kind)
+	if slices.Contains(pins, name) {
+		fmt.Fprintf(os.Stderr, "Already pinned %s: %s\n", kind, name)
+		return nil
+	}
+	if dryRun {
+		fmt.Fprintf(os.Stderr, "Dry run: would pin %s: %s\n", kind, name)
+		return nil
+	}
+	if err := savePins(kind, append(pins, name)); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Pinned %s: %s\n", kind, name)
+	return nil
+}
+
+func unpin(kind, name string) error {
+	pins := readPins(kind)
+	i := slices.Index(pins, name)
+	if i < 0 {
+		return notFoundErrorf("%s %q is not pinned", kind, name)
+	}
+	if dryRun {
+		fmt.Fprintf(os.Stderr, "Dry run: would unpin %s: %s\n", kind, name)
+		return nil
+	}
+	if err := savePins(kind, slices.Delete(pins, i, i+1)); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Unpinned %s: %s\n", kind, name)
+	return nil
+}
+
+// pinnedFirst moves the pinned items to the front, keeping the order of
+// both groups.
+func pinnedFirst[T any](items []T, name func(T) string, pins map[string]bool) {
+	slices.SortStableFunc(items, func(a, b T) int {
+		pa, pb := pins[name(a)], pins[name(b)]
+		switch {
+		case pa && !pb:
+			return -1
+		case pb && !pa:
+			return 1
+		}
+		return 0
+	})
+}
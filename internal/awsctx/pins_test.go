@@ -0,0 +1,124 @@
+package awsctx
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestPinUnpin(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	for _, args := range [][]string{
+		{"awsctx", "p", "pin", "staging"},
+		{"awsctx", "p", "pin", "dev"},
+		{"awsctx", "p", "pin", "staging"},
+		{"awsctx", "r", "pin", "eu-north-1"},
+	} {
+		if err := Run(args); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+	}
+	if got := readPins("profile"); !reflect.DeepEqual(got, []string{"staging", "dev"}) {
+		t.Errorf("profile pins = %v", got)
+	}
+	if got := readPins("region"); !reflect.DeepEqual(got, []string{"eu-north-1"}) {
+		t.Errorf("region pins = %v", got)
+	}
+
+	if err := Run([]string{"awsctx", "p", "unpin", "staging"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readPins("profile"); !reflect.DeepEqual(got, []string{"dev"}) {
+		t.Errorf("profile pins after unpin = %v", got)
+	}
+
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{[]string{"awsctx", "p", "pin", "nope"}, exitNotFound},
+		{[]string{"awsctx", "r", "pin", "mars-1"}, exitNotFound},
+		{[]string{"awsctx", "p", "unpin", "staging"}, exitNotFound},
+		{[]string{"awsctx", "p", "pin"}, exitUsage},
+	}
+	for _, tt := range tests {
+		if err := Run(tt.args); ExitCode(err) != tt.code {
+			t.Errorf("%v: expected exit code %d, got %v", tt.args[1:], tt.code, err)
+		}
+	}
+}
+
+func TestPinUnpin_NameWithSpaces(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig+"\n[profile my team]\nregion = eu-west-1\n", "")
+	defer cleanup()
+
+	if err := Run([]string{"awsctx", "p", "pin", "my team"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readPins("profile"); !reflect.DeepEqual(got, []string{"my team"}) {
+		t.Errorf("profile pins = %v", got)
+	}
+	if !pinnedSet("profile")["my team"] {
+		t.Error("expected my team to be pinned")
+	}
+	if err := Run([]string{"awsctx", "p", "unpin", "my team"}); err != nil {
+		t.Fatalf("unpin: %v", err)
+	}
+	if got := readPins("profile"); len(got) != 0 {
+		t.Errorf("profile pins after unpin = %v", got)
+	}
+}
+
+func TestPin_DryRun(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	if err := Run([]string{"awsctx", "p", "pin", "dev", "--dry-run"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readPins("profile"); got != nil {
+		t.Errorf("dry run saved pins %v", got)
+	}
+}
+
+func TestListPinned(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+	savePins("profile", []string{"staging"})
+	savePins("region", []string{"eu-north-1", "ap-south-1"})
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"awsctx", "p", "--color=never"}, "staging  (pinned)\ndefault\ndev\n"},
+		{[]string{"awsctx", "p", "--pinned"}, "staging  (pinned)\n"},
+		{[]string{"awsctx", "r", "--pinned"}, "eu-north-1      (pinned)\nap-south-1      (pinned)\n"},
+	}
+	for _, tt := range tests {
+		out := captureStdout(t, func() {
+			if err := Run(tt.args); err != nil {
+				t.Errorf("%v: %v", tt.args, err)
+			}
+		})
+		if out != tt.want {
+			t.Errorf("%v:\ngot  %q\nwant %q", tt.args[1:], out, tt.want)
+		}
+	}
+
+	out := captureStdout(t, func() { Run([]string{"awsctx", "p", "-o", "json"}) })
+	var records []profileRecord
+	if err := json.Unmarshal([]byte(out), &records); err != nil {
+		t.Fatal(err)
+	}
+	if records[0].Name != "staging" || !records[0].Pinned || records[1].Pinned {
+		t.Errorf("expected only staging pinned and first: %+v", records)
+	}
+
+	items, _ := pickItems("region")
+	if items[0].Name != "eu-north-1" || items[0].Label != "eu-north-1      (pinned)" {
+		t.Errorf("expected pinned regions first in the picker, got %+v", items[0])
+	}
+}
@@ -0,0 +1,145 @@
+package awsctx
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// fzfPreview prints the details of a profile or region (kind) for the fzf
+// preview pane. Secrets are masked.
+func fzfPreview(kind, name string) error {
+	switch kind {
+	case "profile":
+		return previewProfile(os.Stdout, name)
+	case "region":
+		return previewRegion(os.Stdout, name)
+	default:
+		return fmt.Errorf("unknown subcommand for --fzf-preview: %s", kind)
+	}
+}
+
+// previewProfile writes a profile's resolved settings: the summary fields,
+// the chain of source profiles and the SSO token expiry, followed by its
+// raw sections from both files.
+func previewProfile(w io.Writer, name string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if !cfg.hasProfile(name) {
+		return profileNotFound(name)
+	}
+	keys := cfg.profileKeys(name)
+	creds := cfg.credentials.getKeys(name)
+
+	account := getProfileAccounts([]string{name})[name]
+	if n := loadAccountNames()[account]; n != "" {
+		account += " (" + n + ")"
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, row := range [][2]string{
+		{"profile", name},
+		{"account", account},
+		{"region", keys["region"]},
+		{"role_arn", keys["role_arn"]},
+		{"sso_account", keys["sso_account_id"]},
+		{"sso_role", keys["sso_role_name"]},
+		{"chain", strings.Join(sourceChain(cfg, name), " -> ")},
+		{"sso_token", ssoTokenStatus(keys)},
+	} {
+		if row[1] != "" {
+			fmt.Fprintf(tw, "%s\t%s\n", row[0], redactString(row[1]))
+		}
+	}
+	tw.Flush()
+
+	if len(keys) > 0 {
+		fmt.Fprintf(w, "\n%s [%s]\n", cfg.config.path, configSection(cfg.config, name))
+		writeKeys(w, redactKeys(keys))
+	}
+	if len(creds) > 0 {
+		fmt.Fprintf(w, "\n%s [%s]\n", cfg.credentials.path, name)
+		writeKeys(w, redactKeys(creds))
+	}
+	return nil
+}
+
+// writeKeys writes settings as sorted "key = value" lines.
+func writeKeys(w io.Writer, keys map[string]string) {
+	names := make([]string, 0, len(keys))
+	for k := range keys {
+		names = append(names, k)
+	}
+	slices.Sort(names)
+	for _, k := range names {
+		fmt.Fprintf(w, "%s = %s\n", k, keys[k])
+	}
+}
+
+// sourceChain follows source_profile from name and describes how each link
+// gets its credentials, e.g. ["dev (assume role)", "base (static keys)"].
+func sourceChain(cfg *Config, name string) []string {
+	var chain []string
+	seen := make(map[string]bool)
+	for p := name; p != ""; {
+		if seen[p] {
+			return append(chain, p+" (cycle)")
+		}
+		seen[p] = true
+
+		keys := cfg.profileKeys(p)
+		kind := credentialKind(keys)
+		if kind == "" && cfg.credentials.getKeys(p)["aws_access_key_id"] != "" {
+			kind = "static keys"
+		}
+		if src := keys["credential_source"]; src != "" {
+			kind += ", credential_source " + src
+		}
+		if kind != "" {
+			p += " (" + kind + ")"
+		}
+		chain = append(chain, p)
+		p = keys["source_profile"]
+	}
+	return chain
+}
+
+// ssoTokenStatus describes the cached SSO token of a profile, or returns ""
+// for profiles that don't use SSO.
+func ssoTokenStatus(keys map[string]string) string {
+	cacheKey := ssoCacheKey(keys)
+	if cacheKey == "" {
+		return ""
+	}
+	expiresAt, err := readSSOExpiry(cacheKey)
+	if err != nil {
+		return "not cached"
+	}
+	expires, err := parseSSOExpiry(expiresAt)
+	if err != nil || time.Now().After(expires) {
+		return "expired " + expiresAt
+	}
+	return "valid until " + expires.Local().Format(time.RFC3339)
+}
+
+// previewRegion writes a region's display name, partition and, if it was
+// measured, latency.
+func previewRegion(w io.Writer, name string) error {
+	if !isValidRegion(name) {
+		return regionNotFound(name)
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "region\t%s\n", name)
+	fmt.Fprintf(tw, "name\t%s\n", regionNames[name])
+	fmt.Fprintf(tw, "partition\t%s\n", regionPartition(name))
+	if d, ok := readLatency()[name]; ok {
+		fmt.Fprintf(tw, "latency\t%s\n", formatRTT(d))
+	}
+	return tw.Flush()
+}
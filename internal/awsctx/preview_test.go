@@ -0,0 +1,152 @@
+package awsctx
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPreviewProfile(t *testing.T) {
+	cleanup := setupTestAWS(t, testAccountsConfig, testCredentials)
+	defer cleanup()
+
+	var b bytes.Buffer
+	if err := previewProfile(&b, "prod-ro"); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"account   111111111111\n",
+		"role_arn  arn:aws:iam::111111111111:role/ReadOnly\n",
+		"chain     prod-ro (assume role) -> default (static keys)\n",
+		"[profile prod-ro]\nrole_arn = arn:aws:iam::111111111111:role/ReadOnly\nsource_profile = default\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("preview missing %q:\n%s", want, out)
+		}
+	}
+
+	b.Reset()
+	if err := previewProfile(&b, "default"); err != nil {
+		t.Fatal(err)
+	}
+	out = b.String()
+	if strings.Contains(out, "default-secret") {
+		t.Errorf("preview leaks a secret:\n%s", out)
+	}
+	if !strings.Contains(out, "aws_secret_access_key = "+redacted) {
+		t.Errorf("expected the masked credentials section:\n%s", out)
+	}
+}
+
+func TestPreviewProfile_NotFound(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	err := previewProfile(&bytes.Buffer{}, "nope")
+	if ExitCode(err) != exitNotFound {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
+
+func TestSourceChain_Cycle(t *testing.T) {
+	config := `[profile a]
+role_arn = arn:aws:iam::111111111111:role/x
+source_profile = b
+
+[profile b]
+role_arn = arn:aws:iam::111111111111:role/y
+source_profile = a
+`
+	cleanup := setupTestAWS(t, config, "")
+	defer cleanup()
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(sourceChain(cfg, "a"), " -> ")
+	if want := "a (assume role) -> b (assume role) -> a (cycle)"; got != want {
+		t.Errorf("sourceChain = %q, want %q", got, want)
+	}
+}
+
+func TestSSOTokenStatus(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	os.MkdirAll(ssoCacheDir(), 0o755)
+
+	keys := map[string]string{"sso_session": "corp"}
+	if got := ssoTokenStatus(keys); got != "not cached" {
+		t.Errorf("without a token: %q", got)
+	}
+
+	expiry := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	writeSSOToken(t, "corp", expiry)
+	if got := ssoTokenStatus(keys); !strings.HasPrefix(got, "valid until ") {
+		t.Errorf("with a valid token: %q", got)
+	}
+
+	writeSSOToken(t, "corp", "2020-01-01T00:00:00UTC")
+	if got := ssoTokenStatus(keys); got != "expired 2020-01-01T00:00:00UTC" {
+		t.Errorf("with an expired token: %q", got)
+	}
+
+	if got := ssoTokenStatus(map[string]string{"region": "us-east-1"}); got != "" {
+		t.Errorf("without SSO: %q", got)
+	}
+}
+
+func writeSSOToken(t *testing.T, cacheKey, expiresAt string) {
+	t.Helper()
+	if err := os.WriteFile(ssoTokenPath(cacheKey), []byte(`{"expiresAt": "`+expiresAt+`"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPreviewRegion(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	var b bytes.Buffer
+	if err := previewRegion(&b, "eu-west-1"); err != nil {
+		t.Fatal(err)
+	}
+	want := "region     eu-west-1\nname       Europe (Ireland)\npartition  aws\n"
+	if b.String() != want {
+		t.Errorf("previewRegion:\ngot  %q\nwant %q", b.String(), want)
+	}
+
+	if err := previewRegion(&b, "mars-1"); ExitCode(err) != exitNotFound {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
+
+func TestRun_FzfPreview(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, testCredentials)
+	defer cleanup()
+
+	out := captureStdout(t, func() {
+		if err := Run([]string{"awsctx", "--fzf-preview", "profile", "dev"}); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+	if !strings.Contains(out, "region   us-west-2") || strings.Contains(out, "dev-secret") {
+		t.Errorf("unexpected preview:\n%s", out)
+	}
+
+	err := Run([]string{"awsctx", "--fzf-preview", "region"})
+	var ce *cliError
+	if !errors.As(err, &ce) || ce.code != exitUsage {
+		t.Errorf("expected a usage error without a name, got %v", err)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	if got := shellQuote("/opt/my apps/it's"); got != `'/opt/my apps/it'\''s'` {
+		t.Errorf("shellQuote = %s", got)
+	}
+}
@@ -3,59 +3,170 @@ package awsctx
 import (
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 )
 
-func handleProfile(args []string) error {
+func newProfileCommand() *command {
+	cmd := &command{
+		Name:    "profile",
+		Aliases: []string{"p"},
+		Args:    "[<name> | <account-id> | -]",
+		Short:   "list or switch AWS profiles",
+		Long: `
+Without a name, lists profiles, or in a terminal picks one with fzf or the
+built-in picker (AWSCTX_PICKER=builtin|fzf|none). With a name or 12-digit
+account ID, switches [default] to that profile; '-' switches back to the
+previous one and 'default' restores the original [default]. A partial name
+switches to the only profile starting with or containing it; when several
+match, the picker opens filtered by it. Pinned profiles are listed first;
+'awsctx p -- pin' switches to a profile named like a subcommand.`,
+		Flags: []*flagDef{
+			{Name: "current", Short: "c", Usage: "show current profile"},
+			{Name: "pinned", Usage: "list only pinned profiles"},
+		},
+		Examples: []string{
+			"awsctx p                  # pick a profile",
+			"awsctx p dev              # switch to dev",
+			"awsctx p pay              # switch to the only profile matching pay",
+			"awsctx p 123456789012     # switch to the profile for an account",
+			"awsctx p -                # switch to the previous profile",
+			"awsctx p pin prod         # list prod first",
+		},
+		Run: runProfile,
+		Complete: firstArg(func() []completion {
+			cands := append(profileCompletions(), accountCompletions()...)
+			return append(cands, completion{"-", "switch to the previous profile"})
+		}),
+	}
+	cmd.add(newPinCommands("profile", profileCompletions, func(name string) error {
+		if !profileExists(name) {
+			return profileNotFound(name)
+		}
+		return nil
+	})...)
+	return cmd
+}
+
+func runProfile(in *invocation, args []string) error {
+	if len(args) > 1 {
+		return usageErrorf("too many arguments: %v", args[1:])
+	}
+	if in.Bool("current") {
+		return showCurrentProfile()
+	}
+
 	if len(args) == 0 {
-		if isInteractive() && hasFzf() {
+		if canPick() && !in.Bool("pinned") {
 			return chooseProfileInteractive()
 		}
-		return listProfiles()
+		return listProfiles(sortFor(false), in.Bool("pinned"))
 	}
 
-	switch args[0] {
-	case "-c", "--current":
-		showCurrentProfile()
-		return nil
-	case "-":
+	name := args[0]
+	switch {
+	case name == "-":
 		return swapProfile()
-	case "-h", "--help":
-		printProfileUsage()
-		return nil
-	default:
-		return setProfile(args[0])
+	case !profileExists(name) && !isAccountID(name):
+		return switchPartial("profile", name, profileNames(), setProfile, profileNotFound)
 	}
+	return setProfile(name)
 }
 
-func listProfiles() error {
-	profiles, err := getProfiles()
+// listProfiles prints the profiles in the given order (see sortFor). With
+// pinnedOnly, only pinned profiles are listed.
+func listProfiles(order string, pinnedOnly bool) error {
+	entries, err := getProfileEntries()
 	if err != nil {
 		return err
 	}
+	entries = sortedProfiles(entries, order)
+	if pinnedOnly {
+		pins := pinnedSet("profile")
+		entries = slices.DeleteFunc(entries, func(e profileEntry) bool { return !pins[e.Name] })
+	}
+	if structuredOutput() {
+		records, err := profileRecords(entries)
+		if err != nil {
+			return err
+		}
+		return writeOutput(os.Stdout, outputFormat, records)
+	}
+
 	cur := currentProfile()
-	for _, p := range profiles {
-		if p == cur {
-			fmt.Fprintf(os.Stderr, "\033[33m\033[40m%s\033[0m\n", p)
-		} else {
-			fmt.Fprintln(os.Stderr, p)
+	labels := profileLabels(entries)
+	for i, e := range entries {
+		label := labels[i]
+		if e.Name == cur {
+			label = highlight(os.Stdout, label)
 		}
+		fmt.Println(listingLine(e.Name, label))
 	}
 	return nil
 }
 
-func showCurrentProfile() {
-	fmt.Fprintln(os.Stderr, currentProfile())
+// profileRecords builds the structured form of profiles. Source is the
+// config file, or the credentials file for credentials-only profiles.
+func profileRecords(entries []profileEntry) ([]profileRecord, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	cur := currentProfile()
+	profiles := make([]string, len(entries))
+	for i, e := range entries {
+		profiles[i] = e.Name
+	}
+	accounts := getProfileAccounts(profiles)
+	names := loadAccountNames()
+	pins := pinnedSet("profile")
+
+	records := make([]profileRecord, 0, len(entries))
+	for _, e := range entries {
+		p := e.Name
+		keys := cfg.profileKeys(p)
+		source := awsConfigPath()
+		if !e.InConfig {
+			source = awsCredentialsPath()
+		}
+		records = append(records, profileRecord{
+			Name:         p,
+			Current:      p == cur,
+			Region:       keys["region"],
+			Account:      accounts[p],
+			AccountName:  names[accounts[p]],
+			Source:       source,
+			SSOStartURL:  keys["sso_start_url"],
+			SSOSession:   keys["sso_session"],
+			SSOAccountID: keys["sso_account_id"],
+			SSORoleName:  keys["sso_role_name"],
+			Pinned:       pins[p],
+		})
+	}
+	return records, nil
+}
+
+func showCurrentProfile() error {
+	if structuredOutput() {
+		records, err := profileRecords([]profileEntry{findProfileEntry(currentProfile())})
+		if err != nil {
+			return err
+		}
+		return writeOutput(os.Stdout, outputFormat, records[0])
+	}
+	fmt.Println(currentProfile())
+	return nil
 }
 
 func setProfile(name string) error {
 	if !profileExists(name) {
-		return fmt.Errorf("profile %q not found in %s", name, awsConfigPath())
+		if isAccountID(name) {
+			return setProfileByAccount(name)
+		}
+		return profileNotFound(name)
 	}
 
 	prev := currentProfile()
-	if prev != name {
-		savePrevious("profile", prev)
-	}
 
 	if err := switchProfileInConfig(name); err != nil {
 		return err
@@ -64,36 +175,48 @@ func setProfile(name string) error {
 		return err
 	}
 
+	if dryRun {
+		fmt.Fprintf(os.Stderr, "Dry run: would switch to profile: %s\n", name)
+		return nil
+	}
+
+	if prev != name {
+		savePrevious("profile", prev)
+	}
 	saveState("profile", name)
+	// The region copied into [default] with the profile, whatever
+	// AWS_REGION says.
+	region := getProfileRegion("default")
+	saveState("region", region)
+	recordHistory(name, region)
+	saveContext(name, region)
 
 	fmt.Fprintf(os.Stderr, "Switched to profile: %s\n", name)
+	warnShadowing("profile")
 	return nil
 }
 
+// setProfileByAccount switches to the only profile belonging to account id.
+func setProfileByAccount(id string) error {
+	matches := profilesForAccount(id)
+	switch len(matches) {
+	case 0:
+		return notFoundErrorf("no profile found for account %s", id)
+	case 1:
+		return setProfile(matches[0])
+	default:
+		return fmt.Errorf("account %s matches several profiles: %s", id, strings.Join(matches, ", "))
+	}
+}
+
 func swapProfile() error {
 	prev := readPrevious("profile")
 	if prev == "" {
-		return fmt.Errorf("no previous profile found")
+		return notFoundErrorf("no previous profile found")
 	}
 	return setProfile(prev)
 }
 
 func chooseProfileInteractive() error {
-	choice, err := runFzf("profile")
-	if err != nil {
-		return err
-	}
-	if choice == "" {
-		return fmt.Errorf("no profile selected")
-	}
-	return setProfile(choice)
-}
-
-func printProfileUsage() {
-	fmt.Fprint(os.Stderr, `USAGE:
-  awsctx profile              list profiles (fzf if available)
-  awsctx profile <NAME>       switch to profile <NAME>
-  awsctx profile -            switch to previous profile
-  awsctx profile -c           show current profile
-`)
+	return choose("profile", "", setProfile)
 }
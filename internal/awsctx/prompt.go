@@ -0,0 +1,153 @@
+package awsctx
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// defaultPromptFormat is the prompt segment without --format.
+const defaultPromptFormat = "☁ {profile}:{region}"
+
+// promptShells are the accepted values of prompt --shell, which wraps the
+// color escapes so the shell does not count them towards the prompt width.
+var promptShells = []string{"bash", "zsh", "tmux"}
+
+// promptSeparators are the characters dropped before {region} when the
+// profile has no region, so the segment does not end in a dangling ':'.
+const promptSeparators = ":@/|- "
+
+// envClasses maps words in profile names to environment classes, which
+// decide the prompt color.
+var envClasses = map[string]string{
+	"prod": "prod", "prd": "prod", "production": "prod", "live": "prod",
+	"staging": "stage", "stage": "stage", "stg": "stage", "preprod": "stage", "uat": "stage", "qa": "stage",
+	"dev": "dev", "develop": "dev", "development": "dev", "test": "dev", "sandbox": "dev", "sbx": "dev", "local": "dev",
+}
+
+// envClassColors are the ANSI colors and tmux colors of each class.
+var envClassColors = map[string][2]string{
+	"prod":  {"\033[31m", "red"},
+	"stage": {"\033[33m", "yellow"},
+	"dev":   {"\033[32m", "green"},
+}
+
+func newPromptCommand() *command {
+	return &command{
+		Name:  "prompt",
+		Short: "print the profile and region for a shell prompt",
+		Long: `
+Prints a segment like '☁ prod:eu-west-1' for shell prompts and status lines.
+It reads AWS_PROFILE and AWS_REGION and the context saved by the last switch,
+one small file, and never the network, so it is cheap to run on every prompt.
+
+--format takes a template with {profile}, {region} and {class}, the
+environment class (prod, stage or dev) guessed from the words of the profile
+name, which also decides the color: red, yellow or green. Without a region,
+{region} is left out with the separator before it. --shell wraps the
+color escapes for bash or zsh prompts or uses tmux styles, and turns color on
+unless --color=never or NO_COLOR is set.`,
+		Flags: []*flagDef{
+			{Name: "format", Arg: "template", Usage: "segment template", Default: defaultPromptFormat},
+			{Name: "shell", Arg: "name", Usage: "wrap colors for this shell's prompt", Choices: promptShells},
+		},
+		Examples: []string{
+			`PS1='$(awsctx prompt --shell bash) '"$PS1"          # bash`,
+			`RPROMPT='$(awsctx prompt --shell zsh)'              # zsh, with setopt prompt_subst`,
+			`awsctx prompt --format '{profile}@{region}'          # prod@eu-west-1`,
+			`set -g status-right '#(awsctx prompt --shell tmux)' # tmux`,
+		},
+		Run: func(in *invocation, args []string) error {
+			if len(args) > 0 {
+				return usageErrorf("prompt takes no arguments")
+			}
+			profile, region := promptContext()
+			shell := in.String("shell")
+			color := useColor(os.Stdout) || (shell != "" && colorMode != "never" && os.Getenv("NO_COLOR") == "")
+			fmt.Println(renderPrompt(in.String("format"), profile, region, shell, color))
+			return nil
+		},
+	}
+}
+
+// promptContext returns the profile and region for the prompt. The
+// environment wins as in currentProfile and currentRegion, then the context
+// saved by the last switch; only before the first switch are the state and
+// config files read.
+func promptContext() (profile, region string) {
+	profile, region, ok := readContext()
+	if !ok {
+		profile, region = currentProfile(), currentRegion()
+		if region == "(none)" {
+			region = ""
+		}
+	}
+	if p := os.Getenv("AWS_PROFILE"); p != "" {
+		profile = p
+	}
+	if r := os.Getenv("AWS_DEFAULT_REGION"); r != "" {
+		region = r
+	}
+	if r := os.Getenv("AWS_REGION"); r != "" {
+		region = r
+	}
+	return profile, region
+}
+
+// envClass returns the environment class of a profile: the class of the
+// first word of its name (split at anything but letters and digits) found
+// in envClasses, or "".
+func envClass(profile string) string {
+	words := strings.FieldsFunc(strings.ToLower(profile), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	for _, w := range words {
+		if class, ok := envClasses[w]; ok {
+			return class
+		}
+	}
+	return ""
+}
+
+// dropRegion removes each {region} from format together with the
+// promptSeparators right before it.
+func dropRegion(format string) string {
+	for {
+		i := strings.Index(format, "{region}")
+		if i < 0 {
+			return format
+		}
+		j := i
+		for j > 0 && strings.IndexByte(promptSeparators, format[j-1]) >= 0 {
+			j--
+		}
+		format = format[:j] + format[i+len("{region}"):]
+	}
+}
+
+// renderPrompt fills in format and, with color, colors the segment by the
+// environment class, using escapes suited to shell. Without a region,
+// {region} is dropped with the separator before it.
+func renderPrompt(format, profile, region, shell string, color bool) string {
+	class := envClass(profile)
+	if region == "" {
+		format = dropRegion(format)
+	}
+	s := strings.NewReplacer("{profile}", profile, "{region}", region, "{class}", class).Replace(format)
+	colors, ok := envClassColors[class]
+	if !color || !ok {
+		return s
+	}
+
+	switch shell {
+	case "bash":
+		// Readline's markers for invisible characters; \[ and \] are not
+		// interpreted in command substitution output.
+		return "\001" + colors[0] + "\002" + s + "\001" + resetStyle + "\002"
+	case "zsh":
+		return "%{" + colors[0] + "%}" + s + "%{" + resetStyle + "%}"
+	case "tmux":
+		return "#[fg=" + colors[1] + "]" + s + "#[default]"
+	}
+	return colors[0] + s + resetStyle
+}
@@ -0,0 +1,175 @@
+package awsctx
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEnvClass(t *testing.T) {
+	tests := map[string]string{
+		"prod":              "prod",
+		"payments-prod-ro":  "prod",
+		"Team.Staging":      "stage",
+		"acme_uat":          "stage",
+		"dev":               "dev",
+		"sandbox-1":         "dev",
+		"default":           "",
+		"product-analytics": "",
+	}
+	for profile, want := range tests {
+		if got := envClass(profile); got != want {
+			t.Errorf("envClass(%q) = %q, want %q", profile, got, want)
+		}
+	}
+}
+
+func TestRenderPrompt(t *testing.T) {
+	tests := []struct {
+		format, profile, shell string
+		color                  bool
+		region, want           string
+	}{
+		{defaultPromptFormat, "prod", "", false, "eu-west-1", "☁ prod:eu-west-1"},
+		{"{profile}@{region} [{class}]", "dev", "", false, "eu-west-1", "dev@eu-west-1 [dev]"},
+		{"{profile}", "prod", "", true, "eu-west-1", "\033[31mprod\033[0m"},
+		{"{profile}", "prod", "bash", true, "eu-west-1", "\001\033[31m\002prod\001\033[0m\002"},
+		{"{profile}", "staging", "zsh", true, "eu-west-1", "%{\033[33m%}staging%{\033[0m%}"},
+		{"{profile}", "dev", "tmux", true, "eu-west-1", "#[fg=green]dev#[default]"},
+		{"{profile}", "default", "zsh", true, "eu-west-1", "default"},
+		{defaultPromptFormat, "ops", "", false, "", "☁ ops"},
+		{"{profile} @ {region} [{class}]", "dev", "", false, "", "dev [dev]"},
+	}
+	for _, tt := range tests {
+		if got := renderPrompt(tt.format, tt.profile, tt.region, tt.shell, tt.color); got != tt.want {
+			t.Errorf("renderPrompt(%q, %q, %q, %q) = %q, want %q", tt.format, tt.profile, tt.region, tt.shell, got, tt.want)
+		}
+	}
+}
+
+func TestPromptContext(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, testCredentials)
+	defer cleanup()
+
+	// Before any switch, the state and config files are read.
+	if p, r := promptContext(); p != "default" || r != "eu-west-1" {
+		t.Errorf("without a saved context: %s %s", p, r)
+	}
+
+	if err := Run([]string{"awsctx", "pick", "staging", "eu-west-1"}); err != nil {
+		t.Fatal(err)
+	}
+	// The saved context is all the prompt reads, even with the config gone.
+	os.Remove(awsConfigPath())
+	if p, r := promptContext(); p != "staging" || r != "eu-west-1" {
+		t.Errorf("after a switch: %s %s", p, r)
+	}
+
+	t.Setenv("AWS_PROFILE", "dev")
+	t.Setenv("AWS_REGION", "ap-south-1")
+	if p, r := promptContext(); p != "dev" || r != "ap-south-1" {
+		t.Errorf("with environment overrides: %s %s", p, r)
+	}
+}
+
+func TestReadContext_NameWithSpaces(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	for _, region := range []string{"eu-west-1", ""} {
+		saveContext("my team", region)
+		if p, r, ok := readContext(); !ok || p != "my team" || r != region {
+			t.Errorf("readContext() = %q, %q, %v, want %q, %q", p, r, ok, "my team", region)
+		}
+	}
+}
+
+func TestSaveContext_FileLevel(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig+"\n[profile ops]\noutput = json\n", testCredentials)
+	defer cleanup()
+
+	// The environment must not leak into the saved context: the prompt
+	// applies it when reading.
+	t.Setenv("AWS_PROFILE", "dev")
+	t.Setenv("AWS_REGION", "ap-south-1")
+
+	tests := []struct {
+		args            []string
+		profile, region string
+	}{
+		{[]string{"awsctx", "p", "staging"}, "staging", "eu-west-1"},
+		{[]string{"awsctx", "r", "eu-north-1"}, "staging", "eu-north-1"},
+		{[]string{"awsctx", "p", "ops"}, "ops", ""},
+	}
+	for _, tt := range tests {
+		if err := Run(tt.args); err != nil {
+			t.Fatalf("%v: %v", tt.args, err)
+		}
+		if p, r, _ := readContext(); p != tt.profile || r != tt.region {
+			t.Errorf("%v: saved context %q %q, want %q %q", tt.args, p, r, tt.profile, tt.region)
+		}
+	}
+}
+
+func TestRun_Prompt(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, testCredentials)
+	defer cleanup()
+	if err := Run([]string{"awsctx", "r", "eu-north-1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	out := captureStdout(t, func() {
+		if err := Run([]string{"awsctx", "prompt", "--format", "{profile}@{region}"}); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+	if out != "default@eu-north-1\n" {
+		t.Errorf("prompt = %q", out)
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	out = captureStdout(t, func() { Run([]string{"awsctx", "prompt", "--shell", "tmux"}) })
+	if out != "☁ default:eu-north-1\n" {
+		t.Errorf("expected NO_COLOR to win over --shell, got %q", out)
+	}
+}
+
+func TestRun_PromptMatchesCurrentRegion(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, testCredentials)
+	defer cleanup()
+
+	run := func(args ...string) string {
+		return captureStdout(t, func() {
+			if err := Run(append([]string{"awsctx"}, args...)); err != nil {
+				t.Errorf("%v: %v", args, err)
+			}
+		})
+	}
+	run("r", "eu-west-3")
+	run("p", "dev")
+
+	region := strings.TrimSpace(run("r", "-c"))
+	if region != "us-west-2" {
+		t.Errorf("r -c = %q, want dev's region us-west-2", region)
+	}
+	if out := run("prompt", "--format", "{profile}:{region}"); out != "dev:"+region+"\n" {
+		t.Errorf("prompt = %q, want dev:%s", out, region)
+	}
+	if r := readState("region"); r != region {
+		t.Errorf("region state = %q, want %q", r, region)
+	}
+}
+
+func BenchmarkPrompt(b *testing.B) {
+	dir := b.TempDir()
+	b.Setenv("XDG_CACHE_HOME", dir)
+	os.MkdirAll(filepath.Join(dir, "awsctx"), 0o755)
+	saveContext("prod", "eu-west-1")
+
+	b.ResetTimer()
+	for range b.N {
+		profile, region := promptContext()
+		renderPrompt(defaultPromptFormat, profile, region, "zsh", true)
+	}
+}
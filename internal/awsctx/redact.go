@@ -0,0 +1,112 @@
+package awsctx
+
+import (
+	"regexp"
+	"strings"
+)
+
+// redacted replaces secret values in anything awsctx prints.
+const redacted = "****"
+
+// sensitiveKeys are INI keys whose values are always secret.
+var sensitiveKeys = []string{
+	"aws_secret_access_key",
+	"aws_session_token",
+	"aws_security_token",
+}
+
+var (
+	// accessKeyRe matches long-term (AKIA) and temporary (ASIA) access key IDs.
+	accessKeyRe = regexp.MustCompile(`\b(AKIA|ASIA)[A-Z0-9]{12}([A-Z0-9]{4})\b`)
+	// tokenRe matches long base64 runs such as secret keys and session tokens.
+	tokenRe = regexp.MustCompile(`[A-Za-z0-9+/]{40,}={0,2}`)
+)
+
+func isSensitiveKey(key string) bool {
+	return contains(sensitiveKeys, strings.ToLower(strings.TrimSpace(key)))
+}
+
+// redactString masks access key IDs (keeping the prefix and last four
+// characters so they stay recognisable) and long token-like strings.
+func redactString(s string) string {
+	s = accessKeyRe.ReplaceAllString(s, "${1}"+strings.Repeat("*", 12)+"${2}")
+	return tokenRe.ReplaceAllStringFunc(s, func(m string) string {
+		if looksRandom(m) {
+			return redacted
+		}
+		return m
+	})
+}
+
+// looksRandom tells base64 secrets from long paths and words: in random
+// base64 the character class (upper, lower, digit, symbol) changes on about
+// two thirds of adjacent pairs, in paths and words on under a third.
+func looksRandom(s string) bool {
+	class := func(c byte) int {
+		switch {
+		case c >= 'A' && c <= 'Z':
+			return 0
+		case c >= 'a' && c <= 'z':
+			return 1
+		case c >= '0' && c <= '9':
+			return 2
+		default:
+			return 3
+		}
+	}
+	changes := 0
+	for i := 1; i < len(s); i++ {
+		if class(s[i]) != class(s[i-1]) {
+			changes++
+		}
+	}
+	return float64(changes) >= 0.32*float64(len(s)-1)
+}
+
+// redactValue masks the value of key if the key is sensitive or the value
+// looks like a secret.
+func redactValue(key, value string) string {
+	if isSensitiveKey(key) && value != "" {
+		return redacted
+	}
+	return redactString(value)
+}
+
+// redactKeys returns a copy of keys, as returned by iniFile.getKeys, with
+// secret values masked.
+func redactKeys(keys map[string]string) map[string]string {
+	out := make(map[string]string, len(keys))
+	for k, v := range keys {
+		out[k] = redactValue(k, v)
+	}
+	return out
+}
+
+// redactLine masks secrets in a raw INI line, keeping "key = value" layout.
+func redactLine(line string) string {
+	k, v, ok := strings.Cut(line, "=")
+	if !ok || strings.HasPrefix(strings.TrimSpace(k), "#") || strings.HasPrefix(strings.TrimSpace(k), ";") {
+		return redactString(line)
+	}
+	if isSensitiveKey(k) && strings.TrimSpace(v) != "" {
+		return strings.TrimRight(k, " ") + " = " + redacted
+	}
+	return k + "=" + redactString(v)
+}
+
+// redactedError masks secrets in an error's message while keeping the
+// wrapped error (and its exit code) reachable.
+type redactedError struct {
+	err error
+}
+
+func (e *redactedError) Error() string { return redactString(e.err.Error()) }
+func (e *redactedError) Unwrap() error { return e.err }
+
+// redactError wraps err so its message is redacted when printed.
+func redactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &redactedError{err: err}
+}
@@ -3,90 +3,243 @@ package awsctx
 import (
 	"fmt"
 	"os"
+	"slices"
+	"time"
 )
 
-func handleRegion(args []string) error {
-	if len(args) == 0 {
-		if isInteractive() && hasFzf() {
-			return chooseRegionInteractive()
+func newRegionCommand() *command {
+	cmd := &command{
+		Name:    "region",
+		Aliases: []string{"r"},
+		Args:    "[<name> | -]",
+		Short:   "list or switch AWS regions",
+		Long: `
+Without a name, lists regions, or in a terminal picks one with fzf or the
+built-in picker (AWSCTX_PICKER=builtin|fzf|none). With a name, sets the
+region of [default]; '-' switches back to the previous one. A partial name
+switches to the only region starting with or containing it; when several
+match, the picker opens filtered by it. With --profile, the region of that
+profile is changed permanently instead. Pinned regions are listed first;
+'awsctx r -- pin' switches to a region named like a subcommand.`,
+		Flags: []*flagDef{
+			{Name: "current", Short: "c", Usage: "show current region"},
+			{Name: "nearest", Usage: "switch to the region with the lowest latency"},
+			{Name: "profile", Arg: "name", Usage: "set the region of this profile instead of [default]", Complete: profileCompletions},
+			{Name: "pinned", Usage: "list only pinned regions"},
+		},
+		Examples: []string{
+			"awsctx r eu-west-1                      # switch to eu-west-1",
+			"awsctx r eu-west-1 --profile staging    # set staging's region",
+			"awsctx r --nearest                      # switch to the closest region",
+			"awsctx r pin eu-west-1                  # list eu-west-1 first",
+		},
+		Run: runRegion,
+		Complete: firstArg(func() []completion {
+			return append(regionCompletions(), completion{"-", "switch to the previous region"})
+		}),
+	}
+	cmd.add(newPinCommands("region", regionCompletions, func(name string) error {
+		if !isValidRegion(name) {
+			return regionNotFound(name)
 		}
-		return listRegions()
+		return nil
+	})...)
+	return cmd
+}
+
+func runRegion(in *invocation, args []string) error {
+	if len(args) > 1 {
+		return usageErrorf("too many arguments: %v", args[1:])
+	}
+	if profile := in.String("profile"); profile != "" {
+		return runProfileRegion(in, profile, args)
 	}
 
-	switch args[0] {
-	case "-c", "--current":
-		showCurrentRegion()
-		return nil
-	case "-":
+	switch {
+	case in.Bool("current"):
+		return showCurrentRegion(currentRegion())
+	case in.Bool("nearest"):
+		region, err := findNearestRegion()
+		if err != nil {
+			return err
+		}
+		return setRegion(region)
+	case len(args) == 0:
+		if canPick() && !in.Bool("pinned") {
+			return chooseRegionInteractive()
+		}
+		return listRegions(currentRegion(), sortFor(false), in.Bool("pinned"))
+	case args[0] == "-":
 		return swapRegion()
-	case "-h", "--help":
-		printRegionUsage()
-		return nil
+	case !isValidRegion(args[0]):
+		return switchPartial("region", args[0], awsRegions, setRegion, regionNotFound)
 	default:
 		return setRegion(args[0])
 	}
 }
 
-func listRegions() error {
-	cur := currentRegion()
-	for _, r := range awsRegions {
+// runProfileRegion handles `awsctx region --profile <name> [<region>]`,
+// which sets the region on a named profile rather than on [default].
+func runProfileRegion(in *invocation, profile string, args []string) error {
+	if !profileExists(profile) {
+		return profileNotFound(profile)
+	}
+
+	switch {
+	case in.Bool("current"):
+		region := getProfileRegion(profile)
+		if region == "" {
+			region = "(none)"
+		}
+		return showCurrentRegion(region)
+	case in.Bool("nearest"):
+		region, err := findNearestRegion()
+		if err != nil {
+			return err
+		}
+		return setProfileRegion(profile, region)
+	case len(args) == 0:
+		if canPick() && !in.Bool("pinned") {
+			_, choice, err := runPicker("region", nil, "")
+			if err != nil {
+				return err
+			}
+			if choice == "" {
+				return cancelledErrorf("no region selected")
+			}
+			return setProfileRegion(profile, choice)
+		}
+		return listRegions(getProfileRegion(profile), sortFor(false), in.Bool("pinned"))
+	case args[0] == "-":
+		return usageErrorf("'-' is not supported with --profile")
+	case !isValidRegion(args[0]):
+		set := func(region string) error { return setProfileRegion(profile, region) }
+		return switchPartial("region", args[0], awsRegions, set, regionNotFound)
+	default:
+		return setProfileRegion(profile, args[0])
+	}
+}
+
+// listRegions prints the regions in the given order (see sortFor),
+// highlighting cur. With pinnedOnly, only pinned regions are listed.
+func listRegions(cur, order string, pinnedOnly bool) error {
+	latency := readLatency()
+	pins := pinnedSet("region")
+	regions := sortedRegions(order)
+	if pinnedOnly {
+		regions = slices.DeleteFunc(regions, func(r string) bool { return !pins[r] })
+	}
+	if structuredOutput() {
+		return writeOutput(os.Stdout, outputFormat, regionRecords(regions, cur, latency))
+	}
+	for _, r := range regions {
+		label := regionLabel(r, latency, pins[r])
 		if r == cur {
-			fmt.Fprintf(os.Stderr, "\033[33m\033[40m%s\033[0m\n", r)
-		} else {
-			fmt.Fprintln(os.Stderr, r)
+			label = highlight(os.Stdout, label)
 		}
+		fmt.Println(listingLine(r, label))
 	}
 	return nil
 }
 
-func showCurrentRegion() {
-	fmt.Fprintln(os.Stderr, currentRegion())
+// regionRecords builds the structured form of regions.
+func regionRecords(regions []string, cur string, latency map[string]time.Duration) []regionRecord {
+	pins := pinnedSet("region")
+	records := make([]regionRecord, 0, len(regions))
+	for _, r := range regions {
+		rec := regionRecord{Name: r, Current: r == cur, Pinned: pins[r]}
+		if d, ok := latency[r]; ok {
+			ms := d.Milliseconds()
+			rec.LatencyMS = &ms
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+func showCurrentRegion(region string) error {
+	if structuredOutput() {
+		if region == "(none)" {
+			region = ""
+		}
+		return writeOutput(os.Stdout, outputFormat, regionRecords([]string{region}, region, readLatency())[0])
+	}
+	fmt.Println(region)
+	return nil
 }
 
 func setRegion(name string) error {
 	if !isValidRegion(name) {
-		return fmt.Errorf("unknown AWS region: %s", name)
+		return regionNotFound(name)
 	}
 
 	prev := currentRegion()
-	if prev != name && prev != "(none)" {
-		savePrevious("region", prev)
-	}
 
 	if err := switchRegionInConfig(name); err != nil {
 		return err
 	}
 
+	if dryRun {
+		fmt.Fprintf(os.Stderr, "Dry run: would switch to region: %s\n", name)
+		return nil
+	}
+
+	if prev != name && prev != "(none)" {
+		savePrevious("region", prev)
+	}
 	saveState("region", name)
+	profile := copiedProfile()
+	recordHistory(profile, name)
+	saveContext(profile, name)
 
 	fmt.Fprintf(os.Stderr, "Switched to region: %s\n", name)
+	warnShadowing("region")
+	return nil
+}
+
+// setProfileRegion permanently sets the region of a named profile. If that
+// profile is the one copied into [default], the switch also applies there.
+func setProfileRegion(profile, name string) error {
+	if !isValidRegion(name) {
+		return regionNotFound(name)
+	}
+
+	active := profile == copiedProfile()
+	prev := currentRegion()
+
+	if err := switchRegionInProfile(profile, name, active); err != nil {
+		return err
+	}
+
+	if dryRun {
+		fmt.Fprintf(os.Stderr, "Dry run: would set region of profile %s to: %s\n", profile, name)
+		return nil
+	}
+
+	if active {
+		if prev != name && prev != "(none)" {
+			savePrevious("region", prev)
+		}
+		saveState("region", name)
+		recordHistory(profile, name)
+		saveContext(profile, name)
+	}
+
+	fmt.Fprintf(os.Stderr, "Set region of profile %s to: %s\n", profile, name)
+	if active {
+		warnShadowing("region")
+	}
 	return nil
 }
 
 func swapRegion() error {
 	prev := readPrevious("region")
 	if prev == "" {
-		return fmt.Errorf("no previous region found")
+		return notFoundErrorf("no previous region found")
 	}
 	return setRegion(prev)
 }
 
 func chooseRegionInteractive() error {
-	choice, err := runFzf("region")
-	if err != nil {
-		return err
-	}
-	if choice == "" {
-		return fmt.Errorf("no region selected")
-	}
-	return setRegion(choice)
-}
-
-func printRegionUsage() {
-	fmt.Fprint(os.Stderr, `USAGE:
-  awsctx region              list regions (fzf if available)
-  awsctx region <NAME>       switch to region <NAME>
-  awsctx region -            switch to previous region
-  awsctx region -c           show current region
-`)
+	return choose("region", "", setRegion)
 }
@@ -1,5 +1,7 @@
 package awsctx
 
+import "strings"
+
 // AWS regions as of 2025. Update periodically.
 var awsRegions = []string{
 	// US
@@ -52,6 +54,58 @@ var awsRegions = []string{
 	"mx-central-1",
 }
 
+// regionNames are the display names of awsRegions, as in the AWS console.
+var regionNames = map[string]string{
+	"us-east-1":      "US East (N. Virginia)",
+	"us-east-2":      "US East (Ohio)",
+	"us-west-1":      "US West (N. California)",
+	"us-west-2":      "US West (Oregon)",
+	"ca-central-1":   "Canada (Central)",
+	"ca-west-1":      "Canada West (Calgary)",
+	"eu-central-1":   "Europe (Frankfurt)",
+	"eu-central-2":   "Europe (Zurich)",
+	"eu-west-1":      "Europe (Ireland)",
+	"eu-west-2":      "Europe (London)",
+	"eu-west-3":      "Europe (Paris)",
+	"eu-north-1":     "Europe (Stockholm)",
+	"eu-south-1":     "Europe (Milan)",
+	"eu-south-2":     "Europe (Spain)",
+	"ap-east-1":      "Asia Pacific (Hong Kong)",
+	"ap-south-1":     "Asia Pacific (Mumbai)",
+	"ap-south-2":     "Asia Pacific (Hyderabad)",
+	"ap-southeast-1": "Asia Pacific (Singapore)",
+	"ap-southeast-2": "Asia Pacific (Sydney)",
+	"ap-southeast-3": "Asia Pacific (Jakarta)",
+	"ap-southeast-4": "Asia Pacific (Melbourne)",
+	"ap-southeast-5": "Asia Pacific (Malaysia)",
+	"ap-northeast-1": "Asia Pacific (Tokyo)",
+	"ap-northeast-2": "Asia Pacific (Seoul)",
+	"ap-northeast-3": "Asia Pacific (Osaka)",
+	"sa-east-1":      "South America (São Paulo)",
+	"af-south-1":     "Africa (Cape Town)",
+	"me-south-1":     "Middle East (Bahrain)",
+	"me-central-1":   "Middle East (UAE)",
+	"il-central-1":   "Israel (Tel Aviv)",
+	"mx-central-1":   "Mexico (Central)",
+}
+
+// regionPartition returns the AWS partition a region belongs to.
+func regionPartition(name string) string {
+	switch {
+	case strings.HasPrefix(name, "cn-"):
+		return "aws-cn"
+	case strings.HasPrefix(name, "us-gov-"):
+		return "aws-us-gov"
+	case strings.HasPrefix(name, "us-isob-"):
+		return "aws-iso-b"
+	case strings.HasPrefix(name, "us-iso-"):
+		return "aws-iso"
+	case strings.HasPrefix(name, "eu-isoe-"):
+		return "aws-iso-e"
+	}
+	return "aws"
+}
+
 func isValidRegion(name string) bool {
 	for _, r := range awsRegions {
 		if r == name {
@@ -33,3 +33,26 @@ func TestRegionListNoDuplicates(t *testing.T) {
 		seen[r] = true
 	}
 }
+
+func TestRegionNames(t *testing.T) {
+	for _, r := range awsRegions {
+		if regionNames[r] == "" {
+			t.Errorf("no display name for %s", r)
+		}
+	}
+}
+
+func TestRegionPartition(t *testing.T) {
+	tests := map[string]string{
+		"us-east-1":      "aws",
+		"cn-north-1":     "aws-cn",
+		"us-gov-west-1":  "aws-us-gov",
+		"us-iso-east-1":  "aws-iso",
+		"us-isob-east-1": "aws-iso-b",
+	}
+	for region, want := range tests {
+		if got := regionPartition(region); got != want {
+			t.Errorf("regionPartition(%q) = %q, want %q", region, got, want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package awsctx
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// AWS SigV4 test suite "get-vanilla".
+func TestSignRequest_GetVanilla(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	creds := awsCredentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+	signRequest(req, nil, creds, "us-east-1", "service", now)
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, " +
+		"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization mismatch\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+// startSTSStub serves GetCallerIdentity for any request signed with AKIADEV.
+func startSTSStub(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Authorization"), "Credential=AKIADEV/") {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `<ErrorResponse><Error><Code>InvalidClientTokenId</Code><Message>bad key</Message></Error></ErrorResponse>`)
+			return
+		}
+		fmt.Fprint(w, `<GetCallerIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <GetCallerIdentityResult>
+    <Arn>arn:aws:iam::123456789012:user/dev</Arn>
+    <UserId>AIDADEV</UserId>
+    <Account>123456789012</Account>
+  </GetCallerIdentityResult>
+</GetCallerIdentityResponse>`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGetCallerIdentity(t *testing.T) {
+	srv := startSTSStub(t)
+	t.Setenv("AWSCTX_STS_ENDPOINT", srv.URL)
+
+	id, err := getCallerIdentity(awsCredentials{AccessKeyID: "AKIADEV", SecretAccessKey: "s"}, "us-east-1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if id.Account != "123456789012" || id.Arn != "arn:aws:iam::123456789012:user/dev" || id.UserID != "AIDADEV" {
+		t.Errorf("unexpected identity: %+v", id)
+	}
+
+	_, err = getCallerIdentity(awsCredentials{AccessKeyID: "AKIAOTHER", SecretAccessKey: "s"}, "us-east-1")
+	if err == nil || !strings.Contains(err.Error(), "InvalidClientTokenId") {
+		t.Errorf("expected InvalidClientTokenId error, got %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+package awsctx
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// identityTTL is how long a cached caller identity is trusted.
+const identityTTL = 12 * time.Hour
+
+// Sources of the credentials returned by resolveCredentials.
+const (
+	credentialsFromEnv     = "environment"
+	credentialsFromProfile = "profile"
+)
+
+// resolveCredentials returns static credentials for profile the way the AWS
+// CLI would find them: environment, then the credentials file, then the
+// config file, and whether they came from the environment or the profile.
+// SSO, assume-role and credential_process are not resolved.
+func resolveCredentials(profile string) (awsCredentials, string, error) {
+	if id := os.Getenv("AWS_ACCESS_KEY_ID"); id != "" {
+		return awsCredentials{
+			AccessKeyID:     id,
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}, credentialsFromEnv, nil
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return awsCredentials{}, "", err
+	}
+	if c, ok := staticCredentials(cfg.credentials.getKeys(profile)); ok {
+		return c, credentialsFromProfile, nil
+	}
+	if c, ok := staticCredentials(cfg.config.getKeys(profileSection(profile))); ok {
+		return c, credentialsFromProfile, nil
+	}
+
+	return awsCredentials{}, "", fmt.Errorf("no static credentials found for profile %q (only access keys are supported)", profile)
+}
+
+func staticCredentials(keys map[string]string) (awsCredentials, bool) {
+	if keys["aws_access_key_id"] == "" || keys["aws_secret_access_key"] == "" {
+		return awsCredentials{}, false
+	}
+	return awsCredentials{
+		AccessKeyID:     keys["aws_access_key_id"],
+		SecretAccessKey: keys["aws_secret_access_key"],
+		SessionToken:    keys["aws_session_token"],
+	}, true
+}
+
+func identityCachePath(profile string) string {
+	return filepath.Join(cacheDir(), "identity_"+url.PathEscape(profile))
+}
+
+// readIdentity returns the cached identity for profile, or nil if there is
+// none younger than identityTTL.
+func readIdentity(profile string) *callerIdentity {
+	path := identityCachePath(profile)
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > identityTTL {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) < 2 {
+		return nil
+	}
+	id := &callerIdentity{Account: lines[0], Arn: lines[1]}
+	if len(lines) > 2 {
+		id.UserID = lines[2]
+	}
+	return id
+}
+
+// saveIdentity caches an identity for profile as account, ARN and user ID lines.
+func saveIdentity(profile string, id *callerIdentity) {
+	dir := cacheDir()
+	os.MkdirAll(dir, 0o755)
+	content := id.Account + "\n" + id.Arn + "\n" + id.UserID + "\n"
+	os.WriteFile(identityCachePath(profile), []byte(content), 0o644)
+}
+
+// whoami resolves the active profile's identity, from cache unless refresh
+// is set or the cache is stale. Identities of credentials from the
+// environment are not cached: they need not belong to the profile, whose
+// cached account is shown in listings and used to switch by account ID.
+func whoami(refresh bool) error {
+	profile := currentProfile()
+	creds, source, err := resolveCredentials(effectiveProfile())
+	if err != nil {
+		return err
+	}
+	cache := source == credentialsFromProfile
+
+	var id *callerIdentity
+	if cache && !refresh {
+		id = readIdentity(profile)
+	}
+	if id == nil {
+		region := currentRegion()
+		if region == "(none)" {
+			region = "us-east-1"
+		}
+		id, err = getCallerIdentity(creds, region)
+		if err != nil {
+			return err
+		}
+		if cache {
+			saveIdentity(profile, id)
+		}
+	}
+
+	if structuredOutput() {
+		return writeOutput(os.Stdout, outputFormat, identityRecord{
+			Profile: profile,
+			Account: id.Account,
+			Arn:     id.Arn,
+			UserID:  id.UserID,
+		})
+	}
+
+	fmt.Printf("profile: %s\n", profile)
+	fmt.Printf("account: %s\n", id.Account)
+	fmt.Printf("arn:     %s\n", id.Arn)
+	return nil
+}
@@ -0,0 +1,54 @@
+package awsctx
+
+import (
+	"os"
+	"testing"
+)
+
+func TestResolveCredentials(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, testCredentials)
+	defer cleanup()
+
+	c, source, err := resolveCredentials("dev")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if c.AccessKeyID != "AKIADEV" || c.SecretAccessKey != "dev-secret" || source != credentialsFromProfile {
+		t.Errorf("unexpected credentials: %+v from %s", c, source)
+	}
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIAENV")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "env-secret")
+	c, source, _ = resolveCredentials("dev")
+	if c.AccessKeyID != "AKIAENV" || source != credentialsFromEnv {
+		t.Errorf("env credentials should win, got %+v from %s", c, source)
+	}
+}
+
+func TestResolveCredentials_Missing(t *testing.T) {
+	cleanup := setupTestAWS(t, testConfig, "")
+	defer cleanup()
+
+	if _, _, err := resolveCredentials("staging"); err == nil {
+		t.Error("expected error when no static credentials exist")
+	}
+}
+
+func TestIdentityCache(t *testing.T) {
+	dir := t.TempDir()
+	os.Setenv("XDG_CACHE_HOME", dir)
+	defer os.Unsetenv("XDG_CACHE_HOME")
+
+	if id := readIdentity("dev"); id != nil {
+		t.Errorf("expected nil, got %+v", id)
+	}
+
+	saveIdentity("dev", &callerIdentity{Account: "123456789012", Arn: "arn:aws:iam::123456789012:user/dev"})
+	id := readIdentity("dev")
+	if id == nil || id.Account != "123456789012" || id.Arn != "arn:aws:iam::123456789012:user/dev" {
+		t.Errorf("unexpected cached identity: %+v", id)
+	}
+	if id := readIdentity("staging"); id != nil {
+		t.Errorf("identity must be cached per profile, got %+v", id)
+	}
+}